@@ -408,6 +408,8 @@ func getModelTemplate() string {
 	import (
 		"database/sql"
 		"time"
+
+		"github.com/go-lazyer/go-north/generator"
 	)
 	
 	const (
@@ -416,6 +418,11 @@ func getModelTemplate() string {
 		{{end}}
 		TABLE_NAME  = "{{ .TableName }}" // 表名
 	)
+
+	// 注册表结构，用于 generator.Validate 检查字段
+	func init() {
+		generator.RegisterSchema(TABLE_NAME{{range $field := .Fields}}, {{ .ColumnNameUpper }}{{end}})
+	}
 	
 	type {{.TableNameUpperCamel}}Model struct {
 		{{range $field := .Fields}}{{ .FieldName }}  {{ .FieldNullType }} ` + "`{{ .FieldOrmTag }} {{ .FieldDefaultTag }}`" + ` // {{ .Comment }}
@@ -537,6 +544,7 @@ func getDaoTemplate() string {
 			package dao
 			
 			import (
+				north "github.com/go-lazyer/go-north"
 				generator "github.com/go-lazyer/go-north/generator"
 				"{{.ModelPackagePath}}"
 				"github.com/pkg/errors"
@@ -616,6 +624,49 @@ func getDaoTemplate() string {
 				}
 				return {{.TableNameLowerCamel}}Map,nil
 			}
+			{{else if gt (len .PrimaryKeyFields) 1}}
+			// query map by primaryKeys，联合主键的每个元素按主键顺序传值，map 的 key 为主键值组成的数组
+			func QueryMapByPrimaryKeys(primaryKeys [][]any) (map[[{{len .PrimaryKeyFields}}]any]model.{{.TableNameUpperCamel}}Model, error) {
+				query := generator.NewTupleInQuery([]string{ {{- range $i,$field := .PrimaryKeyFields}}{{if ne $i 0}}, {{end}}model.{{ .ColumnNameUpper }}{{end -}} }, primaryKeys)
+				gen := generator.NewGenerator().Table(model.TABLE_NAME).Where(query)
+				sqlStr, params, err := gen.SelectSql(true)
+				if err != nil {
+					return nil, errors.WithStack(err)
+				}
+				return QueryMapBySql(sqlStr, params)
+			}
+
+			// query map by gen
+			func QueryMapByGen(gen *generator.Generator) (map[[{{len .PrimaryKeyFields}}]any]model.{{.TableNameUpperCamel}}Model, error) {
+				sqlStr, params, err := gen.SelectSql(true)
+				if err != nil {
+					return nil, errors.WithStack(err)
+				}
+				return QueryMapBySql(sqlStr, params)
+			}
+
+			// query map by sql
+			func QueryMapBySql(sqlStr string, params []any) (map[[{{len .PrimaryKeyFields}}]any]model.{{.TableNameUpperCamel}}Model, error) {
+				ds, err := database.DataSource()
+				if err != nil {
+					return nil, errors.WithStack(err)
+				}
+				maps, err := ds.PrepareQuery(sqlStr, params)
+				if err != nil {
+					return nil, errors.WithStack(err)
+				}
+				{{.TableNameLowerCamel}}s := model.SliceToStructs(maps)
+
+				if len({{.TableNameLowerCamel}}s) == 0 {
+					return nil, nil
+				}
+				{{.TableNameLowerCamel}}Map := make(map[[{{len .PrimaryKeyFields}}]any]model.{{.TableNameUpperCamel}}Model, len({{.TableNameLowerCamel}}s))
+				for _, {{.TableNameLowerCamel}} := range {{.TableNameLowerCamel}}s {
+					key := [{{len .PrimaryKeyFields}}]any{ {{- range $i,$field := .PrimaryKeyFields}}{{if ne $i 0}}, {{end}}{{$.TableNameLowerCamel}}.{{ .FieldName }}.{{ .FieldNullTypeValue }}{{end -}} }
+					{{.TableNameLowerCamel}}Map[key] = {{.TableNameLowerCamel}}
+				}
+				return {{.TableNameLowerCamel}}Map, nil
+			}
 			{{end}}
 			// count by gen
 			func CountByGen(gen *generator.Generator) (int64, error) {
@@ -661,6 +712,19 @@ func getDaoTemplate() string {
 				return {{.TableNameLowerCamel}}s,nil
 			}
 
+			// paginate by gen, returns the current page and total
+			func PaginateByGen(gen *generator.Generator) (*north.Page[model.{{.TableNameUpperCamel}}Model], error) {
+				ds, err := database.DataSource()
+				if err != nil {
+					return nil, errors.WithStack(err)
+				}
+				page, err := ds.Paginate(gen)
+				if err != nil {
+					return nil, errors.WithStack(err)
+				}
+				return north.MapPage(page, model.SliceToStructs), nil
+			}
+
 
 			// query extend by gen
 			func QueryExtendByGen(gen *generator.Generator) ([]model.{{.TableNameUpperCamel}}Extend, error) {
@@ -729,12 +793,17 @@ func getDaoTemplate() string {
 				return UpdateByGen(gen)
 			}
 			{{end}}
+			// 设置了 MaxAffected 时影响行数超过限制会回滚
 			func UpdateByGen(gen *generator.Generator) (int64, error) {
-				sqlStr, params, err := gen.UpdateSql(true)
+				ds, err := database.DataSource()
 				if err != nil {
 					return 0, errors.WithStack(err)
 				}
-				return UpdateBySql(sqlStr, params)
+				count, err := ds.UpdateByGen(gen)
+				if err != nil {
+					return 0, errors.WithStack(err)
+				}
+				return count, nil
 			}
 			
 			func UpdateBySql(sqlStr string, params []any) (int64, error) {
@@ -749,11 +818,12 @@ func getDaoTemplate() string {
 				return count, nil
 			}
 			{{ if gt (len .PrimaryKeyFields) 0 -}}
-			// 批量更新，updateMaps中必须包含主键，联合主键的表不适应x该方法
+			// 批量更新，updateMaps中必须包含主键，联合主键时必须包含所有主键字段
 			func UpdateByMaps(updateMaps []map[string]any) (int64, error) {
 				if updateMaps == nil || len(updateMaps) == 0 {
 					return 0, nil
 				}
+				{{ if eq (len .PrimaryKeyFields) 1 -}}
 				ids := make([]any, 0)
 				for _, updateMap := range updateMaps {
 					if value, ok := updateMap[model.{{(index .PrimaryKeyFields 0).ColumnNameUpper}}]; ok {
@@ -765,11 +835,24 @@ func getDaoTemplate() string {
 				}
 				query := generator.NewInQuery(model.{{(index .PrimaryKeyFields 0).ColumnNameUpper}}, ids)
 				gen := generator.NewGenerator().Primary(model.{{(index .PrimaryKeyFields 0).ColumnNameUpper}}).Table(model.TABLE_NAME).Where(query).Updates(updateMaps)
-				sqlStr, params, err := gen.UpdateSql(true)
-				if err != nil {
-					return 0, errors.WithStack(err)
+				{{ else -}}
+				primarys := []string{ {{- range $i,$field := .PrimaryKeyFields}}{{if ne $i 0}}, {{end}}model.{{ .ColumnNameUpper }}{{end -}} }
+				keys := make([][]any, 0, len(updateMaps))
+				for _, updateMap := range updateMaps {
+					key := make([]any, 0, len(primarys))
+					for _, primary := range primarys {
+						value, ok := updateMap[primary]
+						if !ok {
+							return 0, errors.New("batch update primary not allowed to be nil")
+						}
+						key = append(key, value)
+					}
+					keys = append(keys, key)
 				}
-				return UpdateBySql(sqlStr, params)
+				query := generator.NewTupleInQuery(primarys, keys)
+				gen := generator.NewGenerator().Primary(primarys...).Table(model.TABLE_NAME).Where(query).Updates(updateMaps)
+				{{ end -}}
+				return UpdateByGen(gen)
 			}
 			{{end}}
 			
@@ -781,29 +864,33 @@ func getDaoTemplate() string {
 				query := generator.NewBoolQuery(){{range $field := .PrimaryKeyFields}} .And(generator.NewEqualQuery(model.{{ .ColumnNameUpper }}, {{ .ColumnNameLowerCamel }})) {{end}}
 				gen := generator.NewGenerator().Table(model.TABLE_NAME).Where(query)
 				{{ end -}}
-				sqlStr, params, err := gen.DeleteSql(true)
-				if err != nil {
-					return 0, errors.WithStack(err)
-				}
-				return DeleteBySql(sqlStr, params)
+				return DeleteByGen(gen)
 			}
 			{{ end -}}
 			{{ if gt (len .PrimaryKeyFields) 0 -}}
+			{{ if eq (len .PrimaryKeyFields) 1 -}}
 			func DeleteByPrimaryKeys(primaryKeys []any) (int64, error) {
 				gen := generator.NewGenerator().Table(model.TABLE_NAME).Where(generator.NewInQuery(model.{{(index .PrimaryKeyFields 0).ColumnNameUpper}}, primaryKeys))
-				sqlStr, params, err := gen.DeleteSql(true)
-				if err != nil {
-					return 0, errors.WithStack(err)
-				}
-				return DeleteBySql(sqlStr, params)
+			{{ else -}}
+			// 联合主键的每个元素按主键顺序传值
+			func DeleteByPrimaryKeys(primaryKeys [][]any) (int64, error) {
+				query := generator.NewTupleInQuery([]string{ {{- range $i,$field := .PrimaryKeyFields}}{{if ne $i 0}}, {{end}}model.{{ .ColumnNameUpper }}{{end -}} }, primaryKeys)
+				gen := generator.NewGenerator().Table(model.TABLE_NAME).Where(query)
+			{{ end -}}
+				return DeleteByGen(gen)
 			}
 			{{ end -}}
+			// 设置了 MaxAffected 时影响行数超过限制会回滚
 			func DeleteByGen(gen *generator.Generator) (int64, error) {
-				sqlStr, params, err := gen.DeleteSql(true)
+				ds, err := database.DataSource()
 				if err != nil {
 					return 0, errors.WithStack(err)
 				}
-				return DeleteBySql(sqlStr, params)
+				count, err := ds.DeleteByGen(gen)
+				if err != nil {
+					return 0, errors.WithStack(err)
+				}
+				return count, nil
 			}
 			func DeleteBySql(sqlStr string, params []any) (int64, error) {
 				ds, err := database.DataSource()
@@ -1,8 +1,12 @@
 package generator
 
 import (
+	"bytes"
 	"fmt"
+	"go/format"
+	"strings"
 	"testing"
+	"text/template"
 	// _ "github.com/go-sql-driver/mysql"
 )
 
@@ -42,3 +46,27 @@ func TestGenModel(t *testing.T) {
 
 	fmt.Println(NewGenerator().Dsn(dsn).Project("go-generator").Gen(tables))
 }
+
+func TestDaoTemplate(t *testing.T) {
+	id := Field{ColumnName: "id", ColumnNameLowerCamel: "id", ColumnNameUpper: "ID", FieldName: "Id", FieldNullType: "sql.NullInt64", FieldNullTypeValue: "Int64", FieldType: "int64"}
+	module := Module{TableName: "user", TableNameUpperCamel: "User", TableNameLowerCamel: "user", Fields: []Field{id}, PrimaryKeyFields: []Field{id}}
+	var buf bytes.Buffer
+	if err := template.Must(template.New("dao").Parse(getDaoTemplate())).Execute(&buf, &module); err != nil {
+		t.Fatal(err)
+	}
+	source, err := format.Source(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	dao := string(source)
+	if !strings.Contains(dao, "ds.UpdateByGen(gen)") || !strings.Contains(dao, "ds.DeleteByGen(gen)") {
+		t.Error("UpdateByGen and DeleteByGen should use DataSource.UpdateByGen and DataSource.DeleteByGen")
+	}
+	for _, name := range []string{"Update", "UpdateByMaps", "DeleteByPrimaryKey", "DeleteByPrimaryKeys"} {
+		body := dao[strings.Index(dao, "func "+name+"("):]
+		body = body[:strings.Index(body, "\n}\n")]
+		if !strings.Contains(body, "ByGen(gen)") {
+			t.Errorf("%s should call ByGen, got %s", name, body)
+		}
+	}
+}
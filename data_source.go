@@ -1 +1,187 @@
 package north
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/go-lazyer/go-north/generator"
+)
+
+// ErrMaxAffected 更新、删除影响的行数超过 Generator.MaxAffected 的限制，语句已回滚
+var ErrMaxAffected = errors.New("affected rows exceeds max affected")
+
+// UpdateByGen 执行 gen 生成的更新语句，设置了 MaxAffected 时在事务中执行，影响行数超过限制时回滚
+func (ds *DataSource) UpdateByGen(gen *generator.Generator) (int64, error) {
+	sqlStr, params, err := gen.UpdateSql(true)
+	if err != nil {
+		return 0, err
+	}
+	return ds.execMaxAffected(sqlStr, params, gen.MaxAffectedRows())
+}
+
+// DeleteByGen 执行 gen 生成的删除语句，设置了 MaxAffected 时在事务中执行，影响行数超过限制时回滚
+func (ds *DataSource) DeleteByGen(gen *generator.Generator) (int64, error) {
+	sqlStr, params, err := gen.DeleteSql(true)
+	if err != nil {
+		return 0, err
+	}
+	return ds.execMaxAffected(sqlStr, params, gen.MaxAffectedRows())
+}
+
+// InsertByGen 执行 gen 生成的插入语句，分表时按分表拆分，多条语句在同一个事务中执行，返回插入的行数
+func (ds *DataSource) InsertByGen(gen *generator.Generator) (int64, error) {
+	sqls, err := gen.InsertShardSqls(true)
+	if err != nil {
+		return 0, err
+	}
+	if len(sqls) == 1 {
+		return ds.PrepareUpdate(sqls[0].Sql, sqls[0].Params)
+	}
+	if ds.Db == nil {
+		return 0, errors.New("db not allowed to be nil,need to instantiate yourself")
+	}
+	tx, err := ds.Db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	var total int64
+	for _, shard := range sqls {
+		sqlStr := prepareConvert(shard.Sql, ds.DriverName)
+		if os.Getenv("sql.log") == "stdout" {
+			fmt.Printf("sql is %v\n", sqlStr)
+			fmt.Printf("params is %v\n", shard.Params)
+		}
+		ret, err := tx.Exec(sqlStr, shard.Params...)
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+		n, err := ret.RowsAffected()
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+		total += n
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+// Page 分页查询的结果，不统计总数时 Total、PageCount 为0，通过 HasMore 判断是否还有下一页
+type Page[T any] struct {
+	Items     []T
+	Total     int64
+	PageNum   int
+	PageSize  int
+	PageCount int
+	HasMore   bool
+}
+
+// MapPage 转换分页结果中的数据，例如 MapPage(page, model.SliceToStructs)
+func MapPage[T any, R any](page *Page[T], convert func([]T) []R) *Page[R] {
+	return &Page[R]{
+		Items:     convert(page.Items),
+		Total:     page.Total,
+		PageNum:   page.PageNum,
+		PageSize:  page.PageSize,
+		PageCount: page.PageCount,
+		HasMore:   page.HasMore,
+	}
+}
+
+// Paginate 分页查询，gen 需要设置 PageNum、PageSize，同时返回总数，总数为0或页码超出范围时不再查询数据
+func (ds *DataSource) Paginate(gen *generator.Generator) (*Page[map[string]any], error) {
+	pageNum, pageSize := gen.Pagination()
+	if pageSize <= 0 {
+		return nil, errors.New("page size is required")
+	}
+	countSql, params, err := gen.PageCountSql(true)
+	if err != nil {
+		return nil, err
+	}
+	total, err := ds.PrepareCount(countSql, params)
+	if err != nil {
+		return nil, err
+	}
+	page := &Page[map[string]any]{
+		Items:     make([]map[string]any, 0),
+		Total:     total,
+		PageNum:   pageNum,
+		PageSize:  pageSize,
+		PageCount: int((total + int64(pageSize) - 1) / int64(pageSize)),
+	}
+	page.HasMore = pageNum < page.PageCount
+	if int64(gen.Offset()) >= total {
+		return page, nil
+	}
+	sqlStr, params, err := gen.SelectSql(true)
+	if err != nil {
+		return nil, err
+	}
+	if page.Items, err = ds.PrepareQuery(sqlStr, params); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// Scroll 不统计总数的分页查询，用于无限滚动，多查一条判断是否还有下一页
+// 从 PageStart 开始查询时不按页对齐，PageNum 只用于返回
+func (ds *DataSource) Scroll(gen *generator.Generator) (*Page[map[string]any], error) {
+	pageNum, pageSize := gen.Pagination()
+	if pageSize <= 0 {
+		return nil, errors.New("page size is required")
+	}
+	sqlStr, params, err := gen.Clone().PageNum(0).PageStart(gen.Offset()).PageSize(pageSize + 1).SelectSql(true)
+	if err != nil {
+		return nil, err
+	}
+	items, err := ds.PrepareQuery(sqlStr, params)
+	if err != nil {
+		return nil, err
+	}
+	page := &Page[map[string]any]{Items: items, PageNum: pageNum, PageSize: pageSize}
+	if len(items) > pageSize {
+		page.Items, page.HasMore = items[:pageSize], true
+	}
+	return page, nil
+}
+
+func (ds *DataSource) execMaxAffected(sqlStr string, params []any, maxAffected int64) (int64, error) {
+	if maxAffected <= 0 {
+		return ds.PrepareUpdate(sqlStr, params)
+	}
+	if ds.Db == nil {
+		return 0, errors.New("db not allowed to be nil,need to instantiate yourself")
+	}
+	sqlStr = prepareConvert(sqlStr, ds.DriverName)
+	serverMode := os.Getenv("sql.log")
+	if serverMode == "stdout" {
+		fmt.Printf("sql is %v\n", sqlStr)
+		fmt.Printf("params is %v\n", params)
+	}
+	tx, err := ds.Db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	ret, err := tx.Exec(sqlStr, params...)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	n, err := ret.RowsAffected() // 操作影响的行数
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	if n > maxAffected {
+		tx.Rollback()
+		return 0, fmt.Errorf("%w: %d > %d", ErrMaxAffected, n, maxAffected)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
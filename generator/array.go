@@ -0,0 +1,75 @@
+package generator
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PgArray postgres 数组参数，Value 时转换成数组的文本格式 {1,2,3}，lib/pq 和 pgx 都可以绑定
+// 元素支持整数、浮点数、布尔、字符串、time.Time、nil 和 driver.Valuer
+type PgArray []any
+
+func (a PgArray) Value() (driver.Value, error) {
+	var buf strings.Builder
+	buf.WriteByte('{')
+	for i, v := range a {
+		if i != 0 {
+			buf.WriteByte(',')
+		}
+		element, err := pgArrayElement(v)
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteString(element)
+	}
+	buf.WriteByte('}')
+	return buf.String(), nil
+}
+
+// pgArrayElement 数组元素的文本格式，字符串和时间加双引号
+func pgArrayElement(value any) (string, error) {
+	if isNullValue(value) {
+		return "NULL", nil
+	}
+	if valuer, ok := value.(driver.Valuer); ok {
+		v, err := valuer.Value()
+		if err != nil {
+			return "", err
+		}
+		return pgArrayElement(v)
+	}
+	switch v := value.(type) {
+	case string:
+		return pgArrayQuote(v), nil
+	case []byte:
+		return "", fmt.Errorf("array element []byte is not supported")
+	case time.Time:
+		return pgArrayQuote(v.Format("2006-01-02 15:04:05.999999Z07:00")), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	}
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Pointer:
+		return pgArrayElement(rv.Elem().Interface())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(rv.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(rv.Float(), 'g', -1, 64), nil
+	case reflect.String:
+		return pgArrayQuote(rv.String()), nil
+	case reflect.Bool:
+		return strconv.FormatBool(rv.Bool()), nil
+	}
+	return "", fmt.Errorf("array element %T is not supported", value)
+}
+
+func pgArrayQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
@@ -0,0 +1,360 @@
+package generator
+
+import "fmt"
+
+// Node 查询条件的语法树节点，Kind 为 DSL_* 常量，不同的条件使用不同的字段：
+//
+//	比较、like       Field、Value
+//	contains、starts_with、ends_with、regexp  Field、Value、IgnoreCase
+//	in、not in       Field、Values、Array
+//	between          Field、From、To
+//	null、not null   Field
+//	字段比较         Field、Other
+//	bool             And、Or
+//
+// 不是内置类型的条件 Kind 为空，Raw 为原条件
+// TimeRangeQuery 的 Table、Field 和 TupleInQuery 的 Table、Fields 可以查看和改写，转换回 Query 时使用改写后的值
+// ExprQuery 和自定义条件看不到字段，只能整体保留或删除，转换回 Query 时原样返回
+type Node struct {
+	Kind   string
+	Table  string
+	Field  string
+	Fields []string
+	Other  string
+	Value  any
+	Values []any
+	From   any
+	To     any
+	And    []*Node
+	Or     []*Node
+	Raw    Query
+
+	IgnoreCase bool
+	Array      *bool
+}
+
+var kindOperators = map[string]string{
+	DSL_EQUAL:               "=",
+	DSL_NOT_EQUAL:           "!=",
+	DSL_NULL_SAFE_EQUAL:     "is not distinct from",
+	DSL_NULL_SAFE_NOT_EQUAL: "is distinct from",
+	DSL_GT:                  ">",
+	DSL_GTE:                 ">=",
+	DSL_LT:                  "<",
+	DSL_LTE:                 "<=",
+	DSL_LIKE:                "like",
+	DSL_NOT_LIKE:            "not like",
+	DSL_CONTAINS:            "like",
+	DSL_NOT_CONTAINS:        "not like",
+	DSL_STARTS_WITH:         "like",
+	DSL_ENDS_WITH:           "like",
+	DSL_ILIKE:               "ilike",
+	DSL_REGEXP:              "regexp",
+	DSL_IN:                  "in",
+	DSL_NOT_IN:              "not in",
+	DSL_BETWEEN:             "between",
+	DSL_NOT_BETWEEN:         "not between",
+	DSL_NULL:                "is null",
+	DSL_NOT_NULL:            "is not null",
+	DSL_FIELD_EQUAL:         "=",
+	DSL_FIELD_NOT_EQUAL:     "!=",
+	DSL_FIELD_GT:            ">",
+	DSL_FIELD_GTE:           ">=",
+	DSL_FIELD_LT:            "<",
+	DSL_FIELD_LTE:           "<=",
+}
+
+// Operator 条件对应的sql运算符，bool 和自定义条件为空
+func (n *Node) Operator() string {
+	return kindOperators[n.Kind]
+}
+
+// Column 带表名的字段，没有表名时为 Field
+func (n *Node) Column() string {
+	if n.Table != "" && !isQualified(n.Field) {
+		return n.Table + "." + n.Field
+	}
+	return n.Field
+}
+
+// NewNode 把查询条件转换成语法树，修改语法树不影响原条件
+func NewNode(query Query) *Node {
+	switch q := query.(type) {
+	case *NullQuery:
+		return &Node{Kind: DSL_NULL, Table: q.table, Field: q.field}
+	case *NotNullQuery:
+		return &Node{Kind: DSL_NOT_NULL, Table: q.table, Field: q.field}
+	case *BetweenQuery:
+		return &Node{Kind: DSL_BETWEEN, Table: q.table, Field: q.field, From: q.firstValue, To: q.secondValue}
+	case *NotBetweenQuery:
+		return &Node{Kind: DSL_NOT_BETWEEN, Table: q.table, Field: q.field, From: q.firstValue, To: q.secondValue}
+	case *EqualQuery:
+		return &Node{Kind: DSL_EQUAL, Table: q.table, Field: q.field, Value: q.value}
+	case *NotEqualQuery:
+		return &Node{Kind: DSL_NOT_EQUAL, Table: q.table, Field: q.field, Value: q.value}
+	case *NullSafeEqualQuery:
+		return &Node{Kind: DSL_NULL_SAFE_EQUAL, Table: q.table, Field: q.field, Value: q.value}
+	case *NullSafeNotEqualQuery:
+		return &Node{Kind: DSL_NULL_SAFE_NOT_EQUAL, Table: q.table, Field: q.field, Value: q.value}
+	case *InQuery:
+		return &Node{Kind: DSL_IN, Table: q.table, Field: q.field, Values: cloneSlice(q.value), Array: q.array}
+	case *NotInQuery:
+		return &Node{Kind: DSL_NOT_IN, Table: q.table, Field: q.field, Values: cloneSlice(q.value), Array: q.array}
+	case *LikeQuery:
+		return &Node{Kind: DSL_LIKE, Table: q.table, Field: q.field, Value: q.value}
+	case *NotLikeQuery:
+		return &Node{Kind: DSL_NOT_LIKE, Table: q.table, Field: q.field, Value: q.value}
+	case *ContainsQuery:
+		return &Node{Kind: DSL_CONTAINS, Table: q.table, Field: q.field, Value: q.value, IgnoreCase: q.ignoreCase}
+	case *NotContainsQuery:
+		return &Node{Kind: DSL_NOT_CONTAINS, Table: q.table, Field: q.field, Value: q.value, IgnoreCase: q.ignoreCase}
+	case *StartsWithQuery:
+		return &Node{Kind: DSL_STARTS_WITH, Table: q.table, Field: q.field, Value: q.value, IgnoreCase: q.ignoreCase}
+	case *EndsWithQuery:
+		return &Node{Kind: DSL_ENDS_WITH, Table: q.table, Field: q.field, Value: q.value, IgnoreCase: q.ignoreCase}
+	case *ILikeQuery:
+		return &Node{Kind: DSL_ILIKE, Table: q.table, Field: q.field, Value: q.value}
+	case *RegexpQuery:
+		return &Node{Kind: DSL_REGEXP, Table: q.table, Field: q.field, Value: q.value, IgnoreCase: q.ignoreCase}
+	case *GreaterThanQuery:
+		return &Node{Kind: DSL_GT, Table: q.table, Field: q.field, Value: q.value}
+	case *GreaterThanOrEqualQuery:
+		return &Node{Kind: DSL_GTE, Table: q.table, Field: q.field, Value: q.value}
+	case *LessThanQuery:
+		return &Node{Kind: DSL_LT, Table: q.table, Field: q.field, Value: q.value}
+	case *LessThanOrEqualQuery:
+		return &Node{Kind: DSL_LTE, Table: q.table, Field: q.field, Value: q.value}
+	case *FieldEqualQuery:
+		return &Node{Kind: DSL_FIELD_EQUAL, Table: q.table, Field: q.firstField, Other: q.secondField}
+	case *FieldNotEqualQuery:
+		return &Node{Kind: DSL_FIELD_NOT_EQUAL, Table: q.table, Field: q.firstField, Other: q.secondField}
+	case *FieldGreaterThanQuery:
+		return &Node{Kind: DSL_FIELD_GT, Table: q.table, Field: q.firstField, Other: q.secondField}
+	case *FieldGreaterThanOrEqualQuery:
+		return &Node{Kind: DSL_FIELD_GTE, Table: q.table, Field: q.firstField, Other: q.secondField}
+	case *FieldLessThanQuery:
+		return &Node{Kind: DSL_FIELD_LT, Table: q.table, Field: q.firstField, Other: q.secondField}
+	case *FieldLessThanOrEqualQuery:
+		return &Node{Kind: DSL_FIELD_LTE, Table: q.table, Field: q.firstField, Other: q.secondField}
+	case *BoolQuery:
+		node := &Node{Kind: DSL_BOOL}
+		for _, child := range q.query {
+			node.And = append(node.And, NewNode(child))
+		}
+		for _, child := range q.orQuery {
+			node.Or = append(node.Or, NewNode(child))
+		}
+		return node
+	case *TimeRangeQuery:
+		return &Node{Table: q.table, Field: q.field, Raw: q}
+	case *TupleInQuery:
+		return &Node{Table: q.table, Fields: cloneSlice(q.fields), Raw: q}
+	}
+	return &Node{Raw: query}
+}
+
+// Query 把语法树转换回查询条件
+func (n *Node) Query() Query {
+	switch n.Kind {
+	case DSL_NULL:
+		return NewNullQueryWithTable(n.Table, n.Field)
+	case DSL_NOT_NULL:
+		return NewNotNullQueryWithTable(n.Table, n.Field)
+	case DSL_BETWEEN:
+		return NewBetweenQueryWithTable(n.Table, n.Field, n.From, n.To)
+	case DSL_NOT_BETWEEN:
+		return NewNotBetweenQueryWithTable(n.Table, n.Field, n.From, n.To)
+	case DSL_EQUAL:
+		return NewEqualQueryWithTable(n.Table, n.Field, n.Value)
+	case DSL_NOT_EQUAL:
+		return NewNotEqualQueryWithTable(n.Table, n.Field, n.Value)
+	case DSL_NULL_SAFE_EQUAL:
+		return NewNullSafeEqualQueryWithTable(n.Table, n.Field, n.Value)
+	case DSL_NULL_SAFE_NOT_EQUAL:
+		return NewNullSafeNotEqualQueryWithTable(n.Table, n.Field, n.Value)
+	case DSL_IN:
+		return &InQuery{table: n.Table, field: n.Field, value: n.Values, array: n.Array}
+	case DSL_NOT_IN:
+		return &NotInQuery{table: n.Table, field: n.Field, value: n.Values, array: n.Array}
+	case DSL_LIKE:
+		return NewLikeQueryWithTable(n.Table, n.Field, n.Value)
+	case DSL_NOT_LIKE:
+		return NewNotLikeQueryWithTable(n.Table, n.Field, n.Value)
+	case DSL_CONTAINS, DSL_NOT_CONTAINS, DSL_STARTS_WITH, DSL_ENDS_WITH, DSL_REGEXP:
+		return patternQuery(n.Kind, n.Table, n.Field, fmt.Sprint(n.Value), n.IgnoreCase)
+	case DSL_ILIKE:
+		return NewILikeQueryWithTable(n.Table, n.Field, n.Value)
+	case DSL_GT:
+		return NewGreaterThanQueryWithTable(n.Table, n.Field, n.Value)
+	case DSL_GTE:
+		return NewGreaterThanOrEqualQueryWithTable(n.Table, n.Field, n.Value)
+	case DSL_LT:
+		return NewLessThanQueryWithTable(n.Table, n.Field, n.Value)
+	case DSL_LTE:
+		return NewLessThanOrEqualQueryWithTable(n.Table, n.Field, n.Value)
+	case DSL_FIELD_EQUAL:
+		return NewFieldEqualQueryWithTable(n.Table, n.Field, n.Other)
+	case DSL_FIELD_NOT_EQUAL:
+		return NewFieldNotEqualQueryWithTable(n.Table, n.Field, n.Other)
+	case DSL_FIELD_GT:
+		return NewFieldGreaterThanQueryWithTable(n.Table, n.Field, n.Other)
+	case DSL_FIELD_GTE:
+		return NewFieldGreaterThanOrEqualQueryWithTable(n.Table, n.Field, n.Other)
+	case DSL_FIELD_LT:
+		return NewFieldLessThanQueryWithTable(n.Table, n.Field, n.Other)
+	case DSL_FIELD_LTE:
+		return NewFieldLessThanOrEqualQueryWithTable(n.Table, n.Field, n.Other)
+	case DSL_BOOL:
+		query := NewBoolQuery()
+		for _, child := range n.And {
+			query.And(child.Query())
+		}
+		for _, child := range n.Or {
+			query.Or(child.Query())
+		}
+		return query
+	}
+	return n.rawQuery()
+}
+
+// rawQuery 不是内置类型的条件，时间范围和多字段 in 使用节点上的表名和字段
+func (n *Node) rawQuery() Query {
+	switch q := n.Raw.(type) {
+	case *TimeRangeQuery:
+		c := *q
+		c.table, c.field = n.Table, n.Field
+		return &c
+	case *TupleInQuery:
+		c := CloneQuery(q).(*TupleInQuery)
+		c.table, c.fields = n.Table, cloneSlice(n.Fields)
+		return c
+	}
+	return n.Raw
+}
+
+// Visitor 遍历语法树时调用，返回 false 时不再遍历该节点的子节点
+type Visitor func(node *Node) bool
+
+// Rewriter 改写语法树节点，先改写子节点再改写父节点，返回 nil 时删除该节点
+type Rewriter func(node *Node) (*Node, error)
+
+// Walk 深度优先遍历语法树
+func (n *Node) Walk(visit Visitor) {
+	if !visit(n) {
+		return
+	}
+	for _, child := range n.And {
+		child.Walk(visit)
+	}
+	for _, child := range n.Or {
+		child.Walk(visit)
+	}
+}
+
+// Rewrite 改写语法树，返回改写后的根节点，根节点被删除时返回 nil
+func (n *Node) Rewrite(rewrite Rewriter) (*Node, error) {
+	and, err := rewriteNodes(n.And, rewrite)
+	if err != nil {
+		return nil, err
+	}
+	or, err := rewriteNodes(n.Or, rewrite)
+	if err != nil {
+		return nil, err
+	}
+	n.And, n.Or = and, or
+	return rewrite(n)
+}
+
+func rewriteNodes(nodes []*Node, rewrite Rewriter) ([]*Node, error) {
+	if nodes == nil {
+		return nil, nil
+	}
+	result := make([]*Node, 0, len(nodes))
+	for _, node := range nodes {
+		child, err := node.Rewrite(rewrite)
+		if err != nil {
+			return nil, err
+		}
+		if child != nil {
+			result = append(result, child)
+		}
+	}
+	return result, nil
+}
+
+// WalkQuery 遍历查询条件
+func WalkQuery(query Query, visit Visitor) {
+	NewNode(query).Walk(visit)
+}
+
+// RewriteQuery 改写查询条件，返回新的条件，不修改原条件，条件被删除时返回 nil
+func RewriteQuery(query Query, rewrite Rewriter) (Query, error) {
+	node, err := NewNode(query).Rewrite(rewrite)
+	if err != nil || node == nil {
+		return nil, err
+	}
+	return node.Query(), nil
+}
+
+// Walk 遍历 where 条件和 join 的条件
+func (s *Generator) Walk(visit Visitor) *Generator {
+	for _, query := range s.querys {
+		WalkQuery(query, visit)
+	}
+	for _, join := range s.joins {
+		for _, queries := range [][]Query{join.on, join.querys, join.orQuerys} {
+			for _, query := range queries {
+				WalkQuery(query, visit)
+			}
+		}
+		if join.subquery != nil {
+			join.subquery.Walk(visit)
+		}
+	}
+	return s
+}
+
+// Rewrite 改写 where 条件和 join 的条件，出错时生成sql返回该错误
+// join 和派生表改写的是拷贝，不影响调用方传入的 Join 和 Generator
+func (s *Generator) Rewrite(rewrite Rewriter) *Generator {
+	var err error
+	if s.querys, err = rewriteQueries(s.querys, rewrite); err != nil {
+		s.setErr(err)
+		return s
+	}
+	for i, join := range s.joins {
+		join = join.Clone()
+		s.joins[i] = join
+		for _, queries := range []*[]Query{&join.on, &join.querys, &join.orQuerys} {
+			if *queries, err = rewriteQueries(*queries, rewrite); err != nil {
+				s.setErr(err)
+				return s
+			}
+		}
+		if join.subquery != nil {
+			join.subquery.Rewrite(rewrite)
+			if join.subquery.err != nil {
+				s.setErr(join.subquery.err)
+				return s
+			}
+		}
+	}
+	return s
+}
+
+func rewriteQueries(queries []Query, rewrite Rewriter) ([]Query, error) {
+	if queries == nil {
+		return nil, nil
+	}
+	result := make([]Query, 0, len(queries))
+	for _, query := range queries {
+		q, err := RewriteQuery(query, rewrite)
+		if err != nil {
+			return nil, err
+		}
+		if q != nil {
+			result = append(result, q)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,192 @@
+package generator
+
+// Clone 深拷贝 Generator，包括查询条件、join、更新和插入的map
+// 一个基础查询可以 Clone 出多个变体分别追加条件，互不影响，也可以在多个goroutine中使用
+func (s *Generator) Clone() *Generator {
+	c := *s
+	c.orderBy = cloneSlice(s.orderBy)
+	c.groupBy = cloneSlice(s.groupBy)
+	c.dateGroups = cloneSlice(s.dateGroups)
+	c.groupExprs = cloneSlice(s.groupExprs)
+	c.resultExprs = cloneSlice(s.resultExprs)
+	c.columns = cloneSlice(s.columns)
+	c.primary = cloneSlice(s.primary)
+	c.querys = cloneQueries(s.querys)
+	if s.joins != nil {
+		c.joins = make([]*Join, 0, len(s.joins))
+		for _, join := range s.joins {
+			c.joins = append(c.joins, join.Clone())
+		}
+	}
+	c.update = cloneMap(s.update)
+	c.insert = cloneMap(s.insert)
+	c.updates = cloneMaps(s.updates)
+	c.inserts = cloneMaps(s.inserts)
+	return &c
+}
+
+// Clone 深拷贝 Join
+func (s *Join) Clone() *Join {
+	c := *s
+	c.on = cloneQueries(s.on)
+	c.using = cloneSlice(s.using)
+	c.querys = cloneQueries(s.querys)
+	c.orQuerys = cloneQueries(s.orQuerys)
+	if s.subquery != nil {
+		c.subquery = s.subquery.Clone()
+	}
+	return &c
+}
+
+// CloneQuery 深拷贝查询条件，自定义的条件实现了 Clone() Query 时调用 Clone，否则原样返回
+func CloneQuery(query Query) Query {
+	switch q := query.(type) {
+	case interface{ Clone() Query }:
+		return q.Clone()
+	case *BoolQuery:
+		c := *q
+		c.query = cloneQueries(q.query)
+		c.orQuery = cloneQueries(q.orQuery)
+		return &c
+	case *InQuery:
+		c := *q
+		c.value = cloneSlice(q.value)
+		return &c
+	case *NotInQuery:
+		c := *q
+		c.value = cloneSlice(q.value)
+		return &c
+	case *TupleInQuery:
+		c := *q
+		c.fields = cloneSlice(q.fields)
+		if q.values != nil {
+			c.values = make([][]any, 0, len(q.values))
+			for _, row := range q.values {
+				c.values = append(c.values, cloneSlice(row))
+			}
+		}
+		return &c
+	case *NullQuery:
+		c := *q
+		return &c
+	case *TimeRangeQuery:
+		c := *q
+		return &c
+	case *NotNullQuery:
+		c := *q
+		return &c
+	case *BetweenQuery:
+		c := *q
+		return &c
+	case *NotBetweenQuery:
+		c := *q
+		return &c
+	case *EqualQuery:
+		c := *q
+		return &c
+	case *NotEqualQuery:
+		c := *q
+		return &c
+	case *NullSafeEqualQuery:
+		c := *q
+		return &c
+	case *NullSafeNotEqualQuery:
+		c := *q
+		return &c
+	case *LikeQuery:
+		c := *q
+		return &c
+	case *ContainsQuery:
+		c := *q
+		return &c
+	case *NotContainsQuery:
+		c := *q
+		return &c
+	case *StartsWithQuery:
+		c := *q
+		return &c
+	case *EndsWithQuery:
+		c := *q
+		return &c
+	case *ILikeQuery:
+		c := *q
+		return &c
+	case *RegexpQuery:
+		c := *q
+		return &c
+	case *NotLikeQuery:
+		c := *q
+		return &c
+	case *GreaterThanQuery:
+		c := *q
+		return &c
+	case *GreaterThanOrEqualQuery:
+		c := *q
+		return &c
+	case *LessThanQuery:
+		c := *q
+		return &c
+	case *LessThanOrEqualQuery:
+		c := *q
+		return &c
+	case *FieldEqualQuery:
+		c := *q
+		return &c
+	case *FieldNotEqualQuery:
+		c := *q
+		return &c
+	case *FieldGreaterThanQuery:
+		c := *q
+		return &c
+	case *FieldGreaterThanOrEqualQuery:
+		c := *q
+		return &c
+	case *FieldLessThanQuery:
+		c := *q
+		return &c
+	case *FieldLessThanOrEqualQuery:
+		c := *q
+		return &c
+	}
+	return query
+}
+
+func cloneQueries(queries []Query) []Query {
+	if queries == nil {
+		return nil
+	}
+	c := make([]Query, 0, len(queries))
+	for _, query := range queries {
+		c = append(c, CloneQuery(query))
+	}
+	return c
+}
+
+func cloneSlice[T any](s []T) []T {
+	if s == nil {
+		return nil
+	}
+	return append(make([]T, 0, len(s)), s...)
+}
+
+func cloneMap(m map[string]any) map[string]any {
+	if m == nil {
+		return nil
+	}
+	c := make(map[string]any, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+func cloneMaps(maps []map[string]any) []map[string]any {
+	if maps == nil {
+		return nil
+	}
+	c := make([]map[string]any, 0, len(maps))
+	for _, m := range maps {
+		c = append(c, cloneMap(m))
+	}
+	return c
+}
@@ -0,0 +1,224 @@
+package generator
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// literal 把参数转换成sql字面量，字符串按方言转义，只用于非预处理sql和调试输出
+func literal(dialect Dialect, value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "NULL"
+	case driver.Valuer:
+		val, err := v.Value()
+		if err != nil {
+			return "NULL"
+		}
+		return literal(dialect, val)
+	case bool:
+		if dialect.isPostgres() {
+			if v {
+				return "TRUE"
+			}
+			return "FALSE"
+		}
+		if v {
+			return "1"
+		}
+		return "0"
+	case string:
+		return quoteString(dialect, v)
+	case []byte:
+		if dialect.isPostgres() {
+			return "'\\x" + hex.EncodeToString(v) + "'"
+		}
+		return "X'" + hex.EncodeToString(v) + "'"
+	case time.Time:
+		return "'" + v.Format("2006-01-02 15:04:05.999999") + "'"
+	}
+	switch reflect.ValueOf(value).Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return fmt.Sprint(value)
+	}
+	return quoteString(dialect, fmt.Sprint(value))
+}
+
+// quoteString 字符串加单引号，单引号转义为两个单引号，mysql 还需要转义反斜杠
+func quoteString(dialect Dialect, s string) string {
+	if !dialect.isPostgres() {
+		s = strings.ReplaceAll(s, `\`, `\\`)
+	}
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// Interpolate 把预处理sql中的占位符替换成参数的字面量，用于日志和排查问题，不要用来执行
+func Interpolate(sql string, params []any, dialect string) (string, error) {
+	d := Dialect{Name: dialect}
+	if n := strings.Count(sql, PLACE_HOLDER_GO); n != len(params) {
+		return "", fmt.Errorf("placeholder count %d does not match params count %d", n, len(params))
+	}
+	var buf bytes.Buffer
+	for _, param := range params {
+		i := strings.Index(sql, PLACE_HOLDER_GO)
+		buf.WriteString(sql[:i])
+		buf.WriteString(literal(d, param))
+		sql = sql[i+len(PLACE_HOLDER_GO):]
+	}
+	buf.WriteString(sql)
+	return buf.String(), nil
+}
+
+// StatementKind 指定 DebugString、Validate、Compile 的语句类型 STATEMENT_*，删除需要指定 STATEMENT_DELETE
+func (s *Generator) StatementKind(kind string) *Generator {
+	switch kind {
+	case "", STATEMENT_SELECT, STATEMENT_INSERT, STATEMENT_UPDATE, STATEMENT_DELETE:
+		s.kind = kind
+	default:
+		s.setErr(fmt.Errorf("invalid statement kind %q", kind))
+	}
+	return s
+}
+
+// debugSql 按 StatementKind 选择语句类型，没有指定时设置了insert为插入，设置了update为更新，否则为查询
+func (s *Generator) debugSql() (string, []any, error) {
+	switch s.kind {
+	case STATEMENT_SELECT:
+		return s.SelectSql(true)
+	case STATEMENT_INSERT:
+		return s.InsertSql(true)
+	case STATEMENT_UPDATE:
+		return s.UpdateSql(true)
+	case STATEMENT_DELETE:
+		return s.DeleteSql(true)
+	}
+	if s.insert != nil || len(s.inserts) > 0 {
+		return s.InsertSql(true)
+	}
+	if s.update != nil || len(s.updates) > 0 {
+		return s.UpdateSql(true)
+	}
+	return s.SelectSql(true)
+}
+
+// DebugString 参数替换到sql中后的完整sql，用于日志和bug反馈
+func (s *Generator) DebugString() string {
+	sql, params, err := s.debugSql()
+	if err != nil {
+		return "error: " + err.Error()
+	}
+	str, err := Interpolate(sql, params, s.dialect.Name)
+	if err != nil {
+		return "error: " + err.Error()
+	}
+	return str
+}
+
+// PrettyString 格式化后的 DebugString
+func (s *Generator) PrettyString() string {
+	return PrettySql(s.DebugString())
+}
+
+// 需要换行的子句，长的在前面
+var clauseKeywords = []string{
+	"left outer join", "right outer join", "full outer join",
+	"inner join", "left join", "right join", "full join", "cross join",
+	"group by", "order by", "union all",
+	"from", "where", "having", "limit", "union", "values", "set", "join",
+}
+
+// PrettySql 格式化sql，合并多余的空白，每个子句单独一行，引号内的内容保持不变
+func PrettySql(sql string) string {
+	sql = normalizeSpace(sql)
+	var buf bytes.Buffer
+	var quote byte
+	depth := 0
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		if quote != 0 {
+			buf.WriteByte(c)
+			if c == '\\' && quote == '\'' && i+1 < len(sql) {
+				i++
+				buf.WriteByte(sql[i])
+			} else if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		switch c {
+		case '\'', '"', '`':
+			quote = c
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ' ':
+			if keyword := clauseAt(sql, i+1); depth == 0 && keyword != "" {
+				buf.WriteString("\n" + sql[i+1:i+1+len(keyword)])
+				i += len(keyword)
+				continue
+			}
+		}
+		buf.WriteByte(c)
+	}
+	return buf.String()
+}
+
+// clauseAt 判断 sql[i:] 是否以子句关键字开头
+func clauseAt(sql string, i int) string {
+	for _, keyword := range clauseKeywords {
+		end := i + len(keyword)
+		if end > len(sql) || !strings.EqualFold(sql[i:end], keyword) {
+			continue
+		}
+		if end == len(sql) || sql[end] == ' ' || sql[end] == '(' {
+			return keyword
+		}
+	}
+	return ""
+}
+
+// normalizeSpace 引号外连续的空白合并为一个空格，去掉括号内侧和逗号前的空格
+func normalizeSpace(sql string) string {
+	var buf bytes.Buffer
+	var quote byte
+	space := false
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		if quote != 0 {
+			buf.WriteByte(c)
+			if c == '\\' && quote == '\'' && i+1 < len(sql) {
+				i++
+				buf.WriteByte(sql[i])
+			} else if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		if c == ' ' || c == '\t' || c == '\n' || c == '\r' {
+			space = true
+			continue
+		}
+		last := byte(0)
+		if buf.Len() > 0 {
+			last = buf.Bytes()[buf.Len()-1]
+		}
+		if space && last != 0 && last != '(' && c != ')' && c != ',' {
+			buf.WriteByte(' ')
+		}
+		space = false
+		if c == '\'' || c == '"' || c == '`' {
+			quote = c
+		}
+		buf.WriteByte(c)
+		space = c == ','
+	}
+	return buf.String()
+}
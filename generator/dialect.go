@@ -0,0 +1,69 @@
+package generator
+
+import "strings"
+
+const (
+	DIALECT_MYSQL    = "mysql"    // mysql
+	DIALECT_POSTGRES = "postgres" // postgres
+)
+
+// Dialect 渲染sql时使用的数据库方言
+type Dialect struct {
+	Name  string // 数据库类型 mysql/postgres，为空时按mysql处理
+	Quote bool   // 是否给表名、字段名加引号，mysql使用反引号，postgres使用双引号
+
+	ArrayIn bool // postgres 的 in、not in 是否绑定一个数组参数 = ANY(?)、<> ALL(?)，mysql 不生效
+
+	bare bool // 字段去掉表名，用于分表合并后外层查询的排序
+}
+
+// DialectQuery 区分数据库方言的查询条件，Generator 渲染时优先调用 DialectSource
+type DialectQuery interface {
+	Query
+	DialectSource(dialect Dialect, table string, prepare bool) (string, []any, error)
+}
+
+func (d Dialect) isPostgres() bool {
+	return d.Name == DIALECT_POSTGRES
+}
+
+// arrayIn in 条件是否使用数组参数，条件上设置的优先
+func (d Dialect) arrayIn(array *bool) bool {
+	if !d.isPostgres() {
+		return false
+	}
+	if array != nil {
+		return *array
+	}
+	return d.ArrayIn
+}
+
+// querySource 渲染查询条件，支持方言的条件使用 DialectSource
+func querySource(query Query, dialect Dialect, table string, prepare bool) (string, []any, error) {
+	if q, ok := query.(DialectQuery); ok {
+		return q.DialectSource(dialect, table, prepare)
+	}
+	return query.Source(table, prepare)
+}
+
+// identifier 校验标识符，需要时加引号
+func (d Dialect) identifier(name string) (string, error) {
+	if err := ValidateIdentifier(name); err != nil {
+		return "", err
+	}
+	if !d.Quote {
+		return name, nil
+	}
+	return quoteIdentifier(name, d.Name), nil
+}
+
+// column 生成带表名的字段，field 已经是 table.column 形式时不再拼接表名
+func (d Dialect) column(table, field string) (string, error) {
+	if d.bare {
+		return d.identifier(field[strings.LastIndex(field, ".")+1:])
+	}
+	if table != "" && !isQualified(field) {
+		field = table + "." + field
+	}
+	return d.identifier(field)
+}
@@ -0,0 +1,602 @@
+package generator
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// 查询条件的json格式，每个条件是只有一个key的对象，key为条件类型:
+//
+//	{"equal":       {"table": "user", "field": "id", "value": 1}}    所有条件的 table 都可省略
+//	{"not_equal":   {"field": "id", "value": 1}}
+//	{"null_safe_equal"|"null_safe_not_equal": {"field": "parent_id", "value": null}}
+//	{"gt"|"gte"|"lt"|"lte": {"field": "age", "value": 20}}
+//	{"like":        {"field": "name", "value": "%lazyer%"}}
+//	{"not_like":    {"field": "name", "value": "%lazyer%"}}
+//	{"contains"|"not_contains"|"starts_with"|"ends_with": {"field": "name", "value": "lazyer", "ignore_case": true}}  ignore_case 可省略
+//	{"ilike":       {"field": "name", "value": "%lazyer%"}}
+//	{"regexp":      {"field": "name", "value": "^lazy", "ignore_case": true}}
+//	{"in":          {"field": "status", "values": [1, 2]}}
+//	{"not_in":      {"field": "status", "values": [1, 2]}}
+//	{"between":     {"field": "age", "from": 10, "to": 20}}
+//	{"not_between": {"field": "age", "from": 10, "to": 20}}
+//	{"null":        {"field": "deleted_at"}}
+//	{"not_null":    {"field": "deleted_at"}}
+//	{"field_equal":     {"field": "user.id", "other": "order.user_id"}}
+//	{"field_not_equal": {"field": "user.id", "other": "order.user_id"}}
+//	{"field_gt"|"field_gte"|"field_lt"|"field_lte": {"table": "user", "field": "update_time", "other": "create_time"}}  table 用于两个字段中未指定表名的
+//	{"time_range":  {"field": "create_time", "from": "2024-03-01T00:00:00Z", "to": "2024-04-01T00:00:00Z"}}  [from, to)，时间为 RFC3339 格式
+//	{"time_range":  {"field": "create_time", "unit": "day", "at": "2024-03-07T08:00:00+08:00", "count": 7, "location": "Asia/Shanghai"}}  count 默认为1，location 可省略
+//	{"tuple_in":    {"fields": ["order_id", "sku"], "values": [[1, "a"], [2, "b"]], "expand": true}}  expand 可省略
+//	{"bool":        {"and": [{...}, {...}], "or": [{...}, {...}]}}  and、or 都可省略
+const (
+	DSL_EQUAL               = "equal"
+	DSL_NOT_EQUAL           = "not_equal"
+	DSL_NULL_SAFE_EQUAL     = "null_safe_equal"
+	DSL_NULL_SAFE_NOT_EQUAL = "null_safe_not_equal"
+	DSL_GT                  = "gt"
+	DSL_GTE                 = "gte"
+	DSL_LT                  = "lt"
+	DSL_LTE                 = "lte"
+	DSL_LIKE                = "like"
+	DSL_NOT_LIKE            = "not_like"
+	DSL_CONTAINS            = "contains"
+	DSL_NOT_CONTAINS        = "not_contains"
+	DSL_STARTS_WITH         = "starts_with"
+	DSL_ENDS_WITH           = "ends_with"
+	DSL_ILIKE               = "ilike"
+	DSL_REGEXP              = "regexp"
+	DSL_IN                  = "in"
+	DSL_NOT_IN              = "not_in"
+	DSL_BETWEEN             = "between"
+	DSL_NOT_BETWEEN         = "not_between"
+	DSL_NULL                = "null"
+	DSL_NOT_NULL            = "not_null"
+	DSL_FIELD_EQUAL         = "field_equal"
+	DSL_FIELD_NOT_EQUAL     = "field_not_equal"
+	DSL_FIELD_GT            = "field_gt"
+	DSL_FIELD_GTE           = "field_gte"
+	DSL_FIELD_LT            = "field_lt"
+	DSL_FIELD_LTE           = "field_lte"
+	DSL_TIME_RANGE          = "time_range"
+	DSL_TUPLE_IN            = "tuple_in"
+	DSL_BOOL                = "bool"
+)
+
+const (
+	DEFAULT_MAX_DEPTH  = 5    // bool 最大嵌套层数
+	DEFAULT_MAX_VALUES = 1000 // in 条件最多的值个数
+)
+
+// ErrNotSerializable 条件不能转换成json，ExprQuery 的表达式和没有实现 json.Marshaler 的自定义条件返回这个错误
+var ErrNotSerializable = errors.New("query cannot be marshaled to json")
+
+func marshalQuery(kind string, body map[string]any) ([]byte, error) {
+	return json.Marshal(map[string]any{kind: body})
+}
+
+func fieldBody(table, field string) map[string]any {
+	body := map[string]any{"field": field}
+	if table != "" {
+		body["table"] = table
+	}
+	return body
+}
+
+func fieldsBody(table, field, other string) map[string]any {
+	body := fieldBody(table, field)
+	body["other"] = other
+	return body
+}
+
+func valueBody(table, field string, value any) map[string]any {
+	body := fieldBody(table, field)
+	body["value"] = value
+	return body
+}
+
+func ignoreCaseBody(table, field string, value any, ignoreCase bool) map[string]any {
+	body := valueBody(table, field, value)
+	if ignoreCase {
+		body["ignore_case"] = true
+	}
+	return body
+}
+
+func (q *NullQuery) MarshalJSON() ([]byte, error) {
+	return marshalQuery(DSL_NULL, fieldBody(q.table, q.field))
+}
+func (q *NotNullQuery) MarshalJSON() ([]byte, error) {
+	return marshalQuery(DSL_NOT_NULL, fieldBody(q.table, q.field))
+}
+func (q *BetweenQuery) MarshalJSON() ([]byte, error) {
+	body := fieldBody(q.table, q.field)
+	body["from"], body["to"] = q.firstValue, q.secondValue
+	return marshalQuery(DSL_BETWEEN, body)
+}
+func (q *NotBetweenQuery) MarshalJSON() ([]byte, error) {
+	body := fieldBody(q.table, q.field)
+	body["from"], body["to"] = q.firstValue, q.secondValue
+	return marshalQuery(DSL_NOT_BETWEEN, body)
+}
+func (q *EqualQuery) MarshalJSON() ([]byte, error) {
+	return marshalQuery(DSL_EQUAL, valueBody(q.table, q.field, q.value))
+}
+func (q *NotEqualQuery) MarshalJSON() ([]byte, error) {
+	return marshalQuery(DSL_NOT_EQUAL, valueBody(q.table, q.field, q.value))
+}
+func (q *NullSafeEqualQuery) MarshalJSON() ([]byte, error) {
+	return marshalQuery(DSL_NULL_SAFE_EQUAL, valueBody(q.table, q.field, q.value))
+}
+func (q *NullSafeNotEqualQuery) MarshalJSON() ([]byte, error) {
+	return marshalQuery(DSL_NULL_SAFE_NOT_EQUAL, valueBody(q.table, q.field, q.value))
+}
+func (q *InQuery) MarshalJSON() ([]byte, error) {
+	body := fieldBody(q.table, q.field)
+	body["values"] = q.value
+	return marshalQuery(DSL_IN, body)
+}
+func (q *NotInQuery) MarshalJSON() ([]byte, error) {
+	body := fieldBody(q.table, q.field)
+	body["values"] = q.value
+	return marshalQuery(DSL_NOT_IN, body)
+}
+func (q *LikeQuery) MarshalJSON() ([]byte, error) {
+	return marshalQuery(DSL_LIKE, valueBody(q.table, q.field, q.value))
+}
+func (q *NotLikeQuery) MarshalJSON() ([]byte, error) {
+	return marshalQuery(DSL_NOT_LIKE, valueBody(q.table, q.field, q.value))
+}
+func (q *ContainsQuery) MarshalJSON() ([]byte, error) {
+	return marshalQuery(DSL_CONTAINS, ignoreCaseBody(q.table, q.field, q.value, q.ignoreCase))
+}
+func (q *NotContainsQuery) MarshalJSON() ([]byte, error) {
+	return marshalQuery(DSL_NOT_CONTAINS, ignoreCaseBody(q.table, q.field, q.value, q.ignoreCase))
+}
+func (q *StartsWithQuery) MarshalJSON() ([]byte, error) {
+	return marshalQuery(DSL_STARTS_WITH, ignoreCaseBody(q.table, q.field, q.value, q.ignoreCase))
+}
+func (q *EndsWithQuery) MarshalJSON() ([]byte, error) {
+	return marshalQuery(DSL_ENDS_WITH, ignoreCaseBody(q.table, q.field, q.value, q.ignoreCase))
+}
+func (q *ILikeQuery) MarshalJSON() ([]byte, error) {
+	return marshalQuery(DSL_ILIKE, valueBody(q.table, q.field, q.value))
+}
+func (q *RegexpQuery) MarshalJSON() ([]byte, error) {
+	return marshalQuery(DSL_REGEXP, ignoreCaseBody(q.table, q.field, q.value, q.ignoreCase))
+}
+func (q *GreaterThanQuery) MarshalJSON() ([]byte, error) {
+	return marshalQuery(DSL_GT, valueBody(q.table, q.field, q.value))
+}
+func (q *GreaterThanOrEqualQuery) MarshalJSON() ([]byte, error) {
+	return marshalQuery(DSL_GTE, valueBody(q.table, q.field, q.value))
+}
+func (q *LessThanQuery) MarshalJSON() ([]byte, error) {
+	return marshalQuery(DSL_LT, valueBody(q.table, q.field, q.value))
+}
+func (q *LessThanOrEqualQuery) MarshalJSON() ([]byte, error) {
+	return marshalQuery(DSL_LTE, valueBody(q.table, q.field, q.value))
+}
+func (q *FieldEqualQuery) MarshalJSON() ([]byte, error) {
+	return marshalQuery(DSL_FIELD_EQUAL, fieldsBody(q.table, q.firstField, q.secondField))
+}
+func (q *FieldNotEqualQuery) MarshalJSON() ([]byte, error) {
+	return marshalQuery(DSL_FIELD_NOT_EQUAL, fieldsBody(q.table, q.firstField, q.secondField))
+}
+func (q *FieldGreaterThanQuery) MarshalJSON() ([]byte, error) {
+	return marshalQuery(DSL_FIELD_GT, fieldsBody(q.table, q.firstField, q.secondField))
+}
+func (q *FieldGreaterThanOrEqualQuery) MarshalJSON() ([]byte, error) {
+	return marshalQuery(DSL_FIELD_GTE, fieldsBody(q.table, q.firstField, q.secondField))
+}
+func (q *FieldLessThanQuery) MarshalJSON() ([]byte, error) {
+	return marshalQuery(DSL_FIELD_LT, fieldsBody(q.table, q.firstField, q.secondField))
+}
+func (q *FieldLessThanOrEqualQuery) MarshalJSON() ([]byte, error) {
+	return marshalQuery(DSL_FIELD_LTE, fieldsBody(q.table, q.firstField, q.secondField))
+}
+func (q *TimeRangeQuery) MarshalJSON() ([]byte, error) {
+	body := fieldBody(q.table, q.field)
+	if q.unit == "" {
+		body["from"], body["to"] = q.start, q.end
+		return marshalQuery(DSL_TIME_RANGE, body)
+	}
+	body["unit"], body["at"], body["count"] = q.unit, q.at, q.count
+	if q.loc != nil {
+		body["location"] = q.loc.String()
+	}
+	return marshalQuery(DSL_TIME_RANGE, body)
+}
+func (q *TupleInQuery) MarshalJSON() ([]byte, error) {
+	body := map[string]any{"fields": q.fields, "values": q.values}
+	if q.table != "" {
+		body["table"] = q.table
+	}
+	if q.expand {
+		body["expand"] = true
+	}
+	return marshalQuery(DSL_TUPLE_IN, body)
+}
+
+// MarshalJSON 表达式可以是任意的sql片段，不能还原成条件，总是返回 ErrNotSerializable
+func (q *ExprQuery) MarshalJSON() ([]byte, error) {
+	return nil, fmt.Errorf("%w: expr query", ErrNotSerializable)
+}
+func (q *BoolQuery) MarshalJSON() ([]byte, error) {
+	and, err := marshalQueries(q.query)
+	if err != nil {
+		return nil, err
+	}
+	body := map[string]any{"and": and}
+	if len(q.orQuery) > 0 {
+		or, err := marshalQueries(q.orQuery)
+		if err != nil {
+			return nil, err
+		}
+		body["or"] = or
+	}
+	return marshalQuery(DSL_BOOL, body)
+}
+
+func marshalQueries(queries []Query) ([]json.RawMessage, error) {
+	raws := make([]json.RawMessage, 0, len(queries))
+	for _, query := range queries {
+		m, ok := query.(json.Marshaler)
+		if !ok {
+			return nil, fmt.Errorf("%w: %T", ErrNotSerializable, query)
+		}
+		raw, err := m.MarshalJSON()
+		if err != nil {
+			return nil, err
+		}
+		raws = append(raws, raw)
+	}
+	return raws, nil
+}
+
+// dslNode 条件的内容，不同条件使用不同的字段
+type dslNode struct {
+	Table  string            `json:"table"`
+	Field  string            `json:"field"`
+	Fields []string          `json:"fields"`
+	Other  string            `json:"other"`
+	Value  json.RawMessage   `json:"value"`
+	Values []json.RawMessage `json:"values"`
+	From   json.RawMessage   `json:"from"`
+	To     json.RawMessage   `json:"to"`
+	And    []json.RawMessage `json:"and"`
+	Or     []json.RawMessage `json:"or"`
+
+	IgnoreCase bool `json:"ignore_case"`
+
+	Unit     string          `json:"unit"`
+	At       json.RawMessage `json:"at"`
+	Count    int             `json:"count"`
+	Location string          `json:"location"`
+	Expand   bool            `json:"expand"`
+}
+
+// QueryParser 把json格式的条件解析成 Query，可以限制字段白名单、嵌套层数、in 的值个数
+type QueryParser struct {
+	allow     AllowList
+	maxDepth  int
+	maxValues int
+}
+
+func NewQueryParser() *QueryParser {
+	return &QueryParser{
+		maxDepth:  DEFAULT_MAX_DEPTH,
+		maxValues: DEFAULT_MAX_VALUES,
+	}
+}
+
+// Allow 字段白名单，设置后json中的field为白名单中的key，并且不允许指定table
+func (p *QueryParser) Allow(allow AllowList) *QueryParser {
+	p.allow = allow
+	return p
+}
+func (p *QueryParser) MaxDepth(maxDepth int) *QueryParser {
+	p.maxDepth = maxDepth
+	return p
+}
+func (p *QueryParser) MaxValues(maxValues int) *QueryParser {
+	p.maxValues = maxValues
+	return p
+}
+
+// ParseQuery 使用默认配置解析json条件
+func ParseQuery(data []byte) (Query, error) {
+	return NewQueryParser().Parse(data)
+}
+
+func (p *QueryParser) Parse(data []byte) (Query, error) {
+	return p.parse(data, 1)
+}
+
+func (p *QueryParser) parse(data []byte, depth int) (Query, error) {
+	if depth > p.maxDepth {
+		return nil, fmt.Errorf("query nesting exceeds max depth %d", p.maxDepth)
+	}
+	var kinds map[string]json.RawMessage
+	if err := json.Unmarshal(data, &kinds); err != nil {
+		return nil, err
+	}
+	if len(kinds) != 1 {
+		return nil, errors.New("query must be an object with exactly one key")
+	}
+	for kind, raw := range kinds {
+		node := dslNode{}
+		decoder := json.NewDecoder(bytes.NewReader(raw))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&node); err != nil {
+			return nil, fmt.Errorf("%s: %w", kind, err)
+		}
+		query, err := p.build(kind, &node, depth)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", kind, err)
+		}
+		return query, nil
+	}
+	return nil, nil
+}
+
+func (p *QueryParser) build(kind string, node *dslNode, depth int) (Query, error) {
+	if kind == DSL_BOOL {
+		query := NewBoolQuery()
+		for _, raw := range node.And {
+			child, err := p.parse(raw, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			query.And(child)
+		}
+		for _, raw := range node.Or {
+			child, err := p.parse(raw, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			query.Or(child)
+		}
+		return query, nil
+	}
+
+	table := ""
+	if node.Table != "" {
+		if p.allow != nil {
+			return nil, errors.New("table is not allowed when allow list is set")
+		}
+		if err := ValidateIdentifier(node.Table); err != nil {
+			return nil, err
+		}
+		table = node.Table
+	}
+	if kind == DSL_TUPLE_IN {
+		return p.tupleIn(table, node)
+	}
+	field, err := p.column(node.Field)
+	if err != nil {
+		return nil, err
+	}
+
+	switch kind {
+	case DSL_NULL:
+		return NewNullQueryWithTable(table, field), nil
+	case DSL_TIME_RANGE:
+		return timeRange(table, field, node)
+	case DSL_NOT_NULL:
+		return NewNotNullQueryWithTable(table, field), nil
+	case DSL_FIELD_EQUAL, DSL_FIELD_NOT_EQUAL, DSL_FIELD_GT, DSL_FIELD_GTE, DSL_FIELD_LT, DSL_FIELD_LTE:
+		other, err := p.column(node.Other)
+		if err != nil {
+			return nil, err
+		}
+		switch kind {
+		case DSL_FIELD_EQUAL:
+			return NewFieldEqualQueryWithTable(table, field, other), nil
+		case DSL_FIELD_NOT_EQUAL:
+			return NewFieldNotEqualQueryWithTable(table, field, other), nil
+		case DSL_FIELD_GT:
+			return NewFieldGreaterThanQueryWithTable(table, field, other), nil
+		case DSL_FIELD_GTE:
+			return NewFieldGreaterThanOrEqualQueryWithTable(table, field, other), nil
+		case DSL_FIELD_LT:
+			return NewFieldLessThanQueryWithTable(table, field, other), nil
+		}
+		return NewFieldLessThanOrEqualQueryWithTable(table, field, other), nil
+	case DSL_IN, DSL_NOT_IN:
+		if len(node.Values) > p.maxValues {
+			return nil, fmt.Errorf("values size %d exceeds max %d", len(node.Values), p.maxValues)
+		}
+		values := make([]any, 0, len(node.Values))
+		for _, raw := range node.Values {
+			value, err := decodeValue(raw)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		if kind == DSL_IN {
+			return NewInQueryWithTable(table, field, values), nil
+		}
+		return NewNotInQueryWithTable(table, field, values), nil
+	case DSL_BETWEEN, DSL_NOT_BETWEEN:
+		from, err := decodeValue(node.From)
+		if err != nil {
+			return nil, err
+		}
+		to, err := decodeValue(node.To)
+		if err != nil {
+			return nil, err
+		}
+		if kind == DSL_BETWEEN {
+			return NewBetweenQueryWithTable(table, field, from, to), nil
+		}
+		return NewNotBetweenQueryWithTable(table, field, from, to), nil
+	}
+
+	value, err := decodeValue(node.Value)
+	if err != nil {
+		return nil, err
+	}
+	switch kind {
+	case DSL_EQUAL:
+		return NewEqualQueryWithTable(table, field, value), nil
+	case DSL_NOT_EQUAL:
+		return NewNotEqualQueryWithTable(table, field, value), nil
+	case DSL_NULL_SAFE_EQUAL:
+		return NewNullSafeEqualQueryWithTable(table, field, value), nil
+	case DSL_NULL_SAFE_NOT_EQUAL:
+		return NewNullSafeNotEqualQueryWithTable(table, field, value), nil
+	case DSL_GT:
+		return NewGreaterThanQueryWithTable(table, field, value), nil
+	case DSL_GTE:
+		return NewGreaterThanOrEqualQueryWithTable(table, field, value), nil
+	case DSL_LT:
+		return NewLessThanQueryWithTable(table, field, value), nil
+	case DSL_LTE:
+		return NewLessThanOrEqualQueryWithTable(table, field, value), nil
+	case DSL_LIKE:
+		return NewLikeQueryWithTable(table, field, value), nil
+	case DSL_NOT_LIKE:
+		return NewNotLikeQueryWithTable(table, field, value), nil
+	case DSL_ILIKE:
+		return NewILikeQueryWithTable(table, field, value), nil
+	case DSL_CONTAINS, DSL_NOT_CONTAINS, DSL_STARTS_WITH, DSL_ENDS_WITH, DSL_REGEXP:
+		text, ok := value.(string)
+		if !ok {
+			return nil, errors.New("value must be string")
+		}
+		return patternQuery(kind, table, field, text, node.IgnoreCase), nil
+	}
+	return nil, fmt.Errorf("unknown query type %q", kind)
+}
+
+// tupleIn 解析多个字段的 in，每行的值个数和字段个数相同
+func (p *QueryParser) tupleIn(table string, node *dslNode) (Query, error) {
+	if len(node.Fields) == 0 {
+		return nil, errors.New("fields cannot be empty")
+	}
+	fields := make([]string, 0, len(node.Fields))
+	for _, field := range node.Fields {
+		column, err := p.column(field)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, column)
+	}
+	if len(node.Values)*len(fields) > p.maxValues {
+		return nil, fmt.Errorf("values size %d exceeds max %d", len(node.Values)*len(fields), p.maxValues)
+	}
+	rows := make([][]any, 0, len(node.Values))
+	for _, raw := range node.Values {
+		var items []json.RawMessage
+		if err := json.Unmarshal(raw, &items); err != nil {
+			return nil, err
+		}
+		if len(items) != len(fields) {
+			return nil, fmt.Errorf("tuple in row %s does not match fields %v", string(raw), node.Fields)
+		}
+		row := make([]any, 0, len(items))
+		for _, item := range items {
+			value, err := decodeValue(item)
+			if err != nil {
+				return nil, err
+			}
+			row = append(row, value)
+		}
+		rows = append(rows, row)
+	}
+	query := NewTupleInQueryWithTable(table, fields, rows)
+	if node.Expand {
+		query.Expand()
+	}
+	return query, nil
+}
+
+// timeRange 解析时间范围，有 unit 时按 unit、at、count 计算边界，否则为 [from, to)
+func timeRange(table, field string, node *dslNode) (Query, error) {
+	if node.Unit == "" {
+		from, err := decodeTime(node.From)
+		if err != nil {
+			return nil, err
+		}
+		to, err := decodeTime(node.To)
+		if err != nil {
+			return nil, err
+		}
+		return NewTimeRangeQueryWithTable(table, field, from, to), nil
+	}
+	if node.Unit != TIME_UNIT_DAY && node.Unit != TIME_UNIT_WEEK && node.Unit != TIME_UNIT_MONTH {
+		return nil, fmt.Errorf("unsupported time unit %q", node.Unit)
+	}
+	at, err := decodeTime(node.At)
+	if err != nil {
+		return nil, err
+	}
+	count := node.Count
+	if count == 0 {
+		count = 1
+	}
+	if count < 0 {
+		return nil, fmt.Errorf("time range count must be positive, got %d", count)
+	}
+	query := &TimeRangeQuery{table: table, field: field, unit: node.Unit, at: at, count: count}
+	if node.Location != "" {
+		loc, err := time.LoadLocation(node.Location)
+		if err != nil {
+			return nil, err
+		}
+		query.In(loc)
+	}
+	return query, nil
+}
+
+// decodeTime 解析 RFC3339 格式的时间
+func decodeTime(raw json.RawMessage) (time.Time, error) {
+	var t time.Time
+	if raw == nil {
+		return t, errors.New("time is required")
+	}
+	err := json.Unmarshal(raw, &t)
+	return t, err
+}
+
+// column 校验字段，有白名单时把对外字段名转换成真实列名
+func (p *QueryParser) column(field string) (string, error) {
+	if field == "" {
+		return "", errors.New("field cannot be empty")
+	}
+	if p.allow != nil {
+		return p.allow.Column(field)
+	}
+	if err := ValidateIdentifier(field); err != nil {
+		return "", err
+	}
+	return field, nil
+}
+
+// decodeValue 解析条件的值，只允许字符串、数字、布尔和null，整数解析为int64
+func decodeValue(raw json.RawMessage) (any, error) {
+	if raw == nil {
+		return nil, errors.New("value is required")
+	}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i, nil
+		}
+		return v.Float64()
+	case string, bool, nil:
+		return v, nil
+	}
+	return nil, fmt.Errorf("unsupported value %s", string(raw))
+}
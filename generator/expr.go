@@ -0,0 +1,299 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var castTypeRegexp = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_ ]*(\(\d+(\s*,\s*\d+)?\))?$`)
+
+// Expr sql表达式，可以是字段、参数、四则运算、函数、cast、coalesce
+// 用于 ResultExpr、GroupByExpr、AddOrderByExpr 和 Eq、Gt 等条件，参数按出现的顺序返回
+type Expr struct {
+	source func(dialect Dialect, table string, prepare bool) (string, []any, error)
+	alias  string
+	err    error
+}
+
+// Col 字段，没有表名时使用 Generator 的表名
+func Col(name string) *Expr {
+	if err := ValidateIdentifier(name); err != nil {
+		return &Expr{err: err}
+	}
+	return &Expr{source: func(dialect Dialect, table string, prepare bool) (string, []any, error) {
+		column, err := dialect.column(table, name)
+		return column, nil, err
+	}}
+}
+
+// Val 参数，prepare 时为占位符
+func Val(value any) *Expr {
+	return &Expr{source: func(dialect Dialect, table string, prepare bool) (string, []any, error) {
+		if prepare {
+			return PLACE_HOLDER_GO, []any{value}, nil
+		}
+		return literal(dialect, value), []any{value}, nil
+	}}
+}
+
+// RawExpr 原样输出的sql片段，参数使用 ? 占位，引号内的 ? 不是占位符，sql 中不能拼接外部输入
+func RawExpr(sql string, params ...any) *Expr {
+	indexes := rawPlaceholders(sql)
+	if len(indexes) != len(params) {
+		return &Expr{err: fmt.Errorf("raw expr %q has %d placeholders but %d params", sql, len(indexes), len(params))}
+	}
+	return &Expr{source: func(dialect Dialect, table string, prepare bool) (string, []any, error) {
+		var buf strings.Builder
+		start := 0
+		for i, index := range indexes {
+			buf.WriteString(sql[start:index])
+			if prepare {
+				buf.WriteString(PLACE_HOLDER_GO)
+			} else {
+				buf.WriteString(literal(dialect, params[i]))
+			}
+			start = index + 1
+		}
+		buf.WriteString(sql[start:])
+		return buf.String(), params, nil
+	}}
+}
+
+// rawPlaceholders sql 中占位符 ? 的位置，跳过引号内的内容
+func rawPlaceholders(sql string) []int {
+	indexes := make([]int, 0)
+	var quote byte
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		if quote != 0 {
+			if c == '\\' && quote == '\'' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		switch c {
+		case '\'', '"', '`':
+			quote = c
+		case '?':
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
+// toExpr 不是 Expr 的值作为参数
+func toExpr(value any) *Expr {
+	if e, ok := value.(*Expr); ok {
+		return e
+	}
+	return Val(value)
+}
+
+// exprList 渲染多个表达式，用 ", " 连接
+func exprList(exprs []*Expr, dialect Dialect, table string, prepare bool) (string, []any, error) {
+	sources := make([]string, 0, len(exprs))
+	params := make([]any, 0)
+	for _, e := range exprs {
+		source, param, err := e.Source(dialect, table, prepare)
+		if err != nil {
+			return "", nil, err
+		}
+		sources = append(sources, source)
+		params = append(params, param...)
+	}
+	return strings.Join(sources, ", "), params, nil
+}
+
+// Fn 函数调用，name 只允许字母、数字和下划线，参数不是 Expr 时作为参数绑定
+func Fn(name string, args ...any) *Expr {
+	if !identifierRegexp.MatchString(name) {
+		return &Expr{err: fmt.Errorf("invalid function name %q", name)}
+	}
+	exprs := make([]*Expr, 0, len(args))
+	for _, arg := range args {
+		exprs = append(exprs, toExpr(arg))
+	}
+	return &Expr{source: func(dialect Dialect, table string, prepare bool) (string, []any, error) {
+		list, params, err := exprList(exprs, dialect, table, prepare)
+		if err != nil {
+			return "", nil, err
+		}
+		return name + "(" + list + ")", params, nil
+	}}
+}
+
+// CountAll count(*)
+func CountAll() *Expr {
+	return RawExpr("count(*)")
+}
+func Count(arg any) *Expr {
+	return Fn("count", arg)
+}
+func Sum(arg any) *Expr {
+	return Fn("sum", arg)
+}
+func Max(arg any) *Expr {
+	return Fn("max", arg)
+}
+func Min(arg any) *Expr {
+	return Fn("min", arg)
+}
+func Avg(arg any) *Expr {
+	return Fn("avg", arg)
+}
+
+// Coalesce 返回第一个不为null的值
+func Coalesce(args ...any) *Expr {
+	if len(args) == 0 {
+		return &Expr{err: errors.New("coalesce needs at least one argument")}
+	}
+	return Fn("coalesce", args...)
+}
+
+// Cast 类型转换 cast(value as typ)，typ 如 char、decimal(10,2)、unsigned
+func Cast(value any, typ string) *Expr {
+	if !castTypeRegexp.MatchString(typ) {
+		return &Expr{err: fmt.Errorf("invalid cast type %q", typ)}
+	}
+	e := toExpr(value)
+	return &Expr{source: func(dialect Dialect, table string, prepare bool) (string, []any, error) {
+		source, params, err := e.Source(dialect, table, prepare)
+		if err != nil {
+			return "", nil, err
+		}
+		return "cast(" + source + " as " + typ + ")", params, nil
+	}}
+}
+
+// binary 二元运算，结果加括号
+func (e *Expr) binary(operator string, other any) *Expr {
+	right := toExpr(other)
+	return &Expr{source: func(dialect Dialect, table string, prepare bool) (string, []any, error) {
+		left, params, err := e.Source(dialect, table, prepare)
+		if err != nil {
+			return "", nil, err
+		}
+		source, param, err := right.Source(dialect, table, prepare)
+		if err != nil {
+			return "", nil, err
+		}
+		return "(" + left + " " + operator + " " + source + ")", append(params, param...), nil
+	}}
+}
+
+func (e *Expr) Add(other any) *Expr {
+	return e.binary("+", other)
+}
+func (e *Expr) Sub(other any) *Expr {
+	return e.binary("-", other)
+}
+func (e *Expr) Mul(other any) *Expr {
+	return e.binary("*", other)
+}
+func (e *Expr) Div(other any) *Expr {
+	return e.binary("/", other)
+}
+
+// As 别名，只在查询字段中生效
+func (e *Expr) As(alias string) *Expr {
+	c := *e
+	c.alias = alias
+	if err := ValidateIdentifier(alias); err != nil && c.err == nil {
+		c.err = err
+	}
+	return &c
+}
+
+// Source 渲染表达式，不包含别名，table 为字段默认的表名
+func (e *Expr) Source(dialect Dialect, table string, prepare bool) (string, []any, error) {
+	if e.err != nil {
+		return "", nil, e.err
+	}
+	return e.source(dialect, table, prepare)
+}
+
+func (e *Expr) Eq(other any) Query {
+	return NewExprQuery(e, "=", other)
+}
+func (e *Expr) Ne(other any) Query {
+	return NewExprQuery(e, "!=", other)
+}
+func (e *Expr) Gt(other any) Query {
+	return NewExprQuery(e, ">", other)
+}
+func (e *Expr) Gte(other any) Query {
+	return NewExprQuery(e, ">=", other)
+}
+func (e *Expr) Lt(other any) Query {
+	return NewExprQuery(e, "<", other)
+}
+func (e *Expr) Lte(other any) Query {
+	return NewExprQuery(e, "<=", other)
+}
+
+var exprOperators = map[string]bool{"=": true, "!=": true, "<>": true, ">": true, ">=": true, "<": true, "<=": true, "like": true, "not like": true}
+
+// ExprQuery 表达式的比较条件，右边不是 Expr 时作为参数绑定，= nil 和 != nil 生成 is null、is not null
+type ExprQuery struct {
+	left     *Expr
+	operator string
+	right    any
+}
+
+func NewExprQuery(left *Expr, operator string, right any) *ExprQuery {
+	return &ExprQuery{left: left, operator: operator, right: right}
+}
+
+func (q *ExprQuery) Source(table string, prepare bool) (string, []any, error) {
+	return q.DialectSource(Dialect{}, table, prepare)
+}
+
+func (q *ExprQuery) DialectSource(dialect Dialect, table string, prepare bool) (string, []any, error) {
+	if !exprOperators[q.operator] {
+		return "", nil, fmt.Errorf("invalid operator %q", q.operator)
+	}
+	left, params, err := q.left.Source(dialect, table, prepare)
+	if err != nil {
+		return "", nil, err
+	}
+	if _, ok := q.right.(*Expr); !ok && isNullValue(q.right) {
+		switch q.operator {
+		case "=":
+			return left + " is null", params, nil
+		case "!=", "<>":
+			return left + " is not null", params, nil
+		}
+	}
+	right, param, err := toExpr(q.right).Source(dialect, table, prepare)
+	if err != nil {
+		return "", nil, err
+	}
+	return left + " " + q.operator + " " + right, append(params, param...), nil
+}
+
+// ResultExpr 追加查询的表达式，在 Result 的字段之后
+func (s *Generator) ResultExpr(exprs ...*Expr) *Generator {
+	s.resultExprs = append(s.resultExprs, exprs...)
+	return s
+}
+
+// GroupByExpr 追加分组的表达式，在 GroupBy 的字段之后
+func (s *Generator) GroupByExpr(exprs ...*Expr) *Generator {
+	s.groupExprs = append(s.groupExprs, exprs...)
+	return s
+}
+
+// AddOrderByExpr 按表达式排序
+func (s *Generator) AddOrderByExpr(expr *Expr, orderByType string) *Generator {
+	t, err := ValidateOrderByType(orderByType)
+	if err != nil {
+		s.setErr(err)
+		return s
+	}
+	s.orderBy = append(s.orderBy, orderBy{expr: expr, orderByType: t})
+	return s
+}
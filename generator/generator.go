@@ -8,39 +8,98 @@ import (
 )
 
 const (
-	INNER_JOIN      = "inner join" // inner  join
-	LEFT_JOIN       = "left join"  // left  join
-	RIGHT_JOIN      = "right join" // right join
-	PLACE_HOLDER_GO = "ⒼⓄ"         //
+	INNER_JOIN      = "inner join"      // inner  join
+	LEFT_JOIN       = "left join"       // left  join
+	RIGHT_JOIN      = "right join"      // right join
+	FULL_JOIN       = "full outer join" // full outer join，只支持postgres
+	CROSS_JOIN      = "cross join"      // cross join
+	PLACE_HOLDER_GO = "ⒼⓄ"              //
 )
 
+var joinTypes = map[string]bool{
+	INNER_JOIN: true,
+	LEFT_JOIN:  true,
+	RIGHT_JOIN: true,
+	FULL_JOIN:  true,
+	CROSS_JOIN: true,
+}
+
+type orderBy struct {
+	name        string
+	expr        *Expr //按表达式排序时不为空
+	orderByType string
+	nulls       string //null 的位置 NULLS_FIRST、NULLS_LAST，为空时按数据库默认
+	values      []any  //按指定的值的顺序排序
+}
+
 type Generator struct {
-	orderBy    []string //排序字段
-	groupBy    []string //分组字段
-	pageStart  int
-	pageSize   int
-	pageNum    int
-	querys     []Query
-	update     map[string]any
-	updates    []map[string]any
-	insert     map[string]any
-	inserts    []map[string]any
-	joins      []*Join
-	tableName  string
-	tableAlias string
-	primary    string //主键
-	columns    []string
+	orderBy      []orderBy   //排序字段
+	groupBy      []string    //分组字段
+	dateGroups   []dateGroup //按日期分组的字段
+	groupExprs   []*Expr     //分组的表达式
+	resultExprs  []*Expr     //查询的表达式
+	pageStart    int
+	pageSize     int
+	pageNum      int
+	querys       []Query
+	update       map[string]any
+	updates      []map[string]any
+	insert       map[string]any
+	inserts      []map[string]any
+	joins        []*Join
+	tableName    string
+	tableAlias   string
+	primary      []string //主键，联合主键时有多个字段
+	columns      []string
+	dialect      Dialect
+	softDelete   string        //软删除字段，为空时使用 RegisterSoftDelete 注册的字段
+	trashed      int           //软删除数据的查询方式
+	forceDelete  bool          //软删除的表也执行物理删除
+	tenant       any           //租户
+	hasTenant    bool          //是否设置了租户
+	ignoreTenant bool          //不附加租户条件
+	shard        ShardStrategy //分表策略，为空时使用 RegisterShard 注册的策略
+	shardTable   string        //分表时实际读写的物理表
+	allowFull    bool          //是否允许不带条件的更新、删除
+	maxAffect    int64         //更新、删除允许影响的最大行数，0为不限制
+	kind         string        //DebugString、Validate、Compile 的语句类型，为空时按设置的内容选择
+	err          error         //构建过程中的错误，生成sql时返回
 }
 
 func NewGenerator() *Generator {
 	return new(Generator)
 }
 
+// setErr 记录构建过程中的第一个错误
+func (s *Generator) setErr(err error) {
+	if s.err == nil {
+		s.err = err
+	}
+}
+
+// Dialect 数据库方言 mysql/postgres，默认mysql
+func (s *Generator) Dialect(name string) *Generator {
+	s.dialect.Name = name
+	return s
+}
+
+// Quote 是否给表名、字段名加引号，mysql使用反引号，postgres使用双引号
+func (s *Generator) Quote(quote bool) *Generator {
+	s.dialect.Quote = quote
+	return s
+}
+
+// ArrayIn postgres 的 in、not in 绑定一个数组参数，值很多时sql更短，执行计划可以复用，mysql 不生效
+func (s *Generator) ArrayIn(arrayIn bool) *Generator {
+	s.dialect.ArrayIn = arrayIn
+	return s
+}
+
 func (s *Generator) Where(query ...Query) *Generator {
 	if s.querys == nil {
 		s.querys = make([]Query, 0)
 	}
-	s.querys = append(s.querys, query...)
+	s.querys = append(s.querys, presentQueries(query)...)
 	return s
 }
 
@@ -78,7 +137,9 @@ func (s *Generator) TableAlias(tableAlias string) *Generator {
 	s.tableAlias = tableAlias
 	return s
 }
-func (s *Generator) Primary(primary string) *Generator {
+
+// Primary 主键，批量更新时使用，联合主键时传多个字段
+func (s *Generator) Primary(primary ...string) *Generator {
 	s.primary = primary
 	return s
 }
@@ -98,221 +159,511 @@ func (s *Generator) PageSize(pageSize int) *Generator {
 	s.pageSize = pageSize
 	return s
 }
-func (s *Generator) OrderBy(orderBy []string) *Generator {
-	s.orderBy = orderBy
+
+// Limit 只取前 limit 条，更新、删除时为 mysql 的 limit n，可以配合 AddOrderBy 分批处理
+func (s *Generator) Limit(limit int) *Generator {
+	s.pageNum = 0
+	s.pageStart = 0
+	s.pageSize = limit
+	return s
+}
+
+// AllowFullTable 允许不带条件的更新、删除，默认不允许，防止误操作全表
+func (s *Generator) AllowFullTable() *Generator {
+	s.allowFull = true
+	return s
+}
+
+// MaxAffected 通过 north.DataSource 执行更新、删除时允许影响的最大行数，超过时回滚
+func (s *Generator) MaxAffected(maxAffected int64) *Generator {
+	s.maxAffect = maxAffected
+	return s
+}
+
+// MaxAffectedRows 更新、删除允许影响的最大行数，0为不限制
+func (s *Generator) MaxAffectedRows() int64 {
+	return s.maxAffect
+}
+
+// OrderBy 排序，每一项为 "字段 asc|desc"，省略排序方式时为asc
+func (s *Generator) OrderBy(orderBys []string) *Generator {
+	s.orderBy = make([]orderBy, 0, len(orderBys))
+	for _, v := range orderBys {
+		fields := strings.Fields(v)
+		if len(fields) == 1 {
+			fields = append(fields, ORDER_ASC)
+		}
+		if len(fields) != 2 {
+			s.setErr(fmt.Errorf("invalid order by %q", v))
+			continue
+		}
+		s.AddOrderBy(fields[0], fields[1])
+	}
 	return s
 }
 func (s *Generator) AddOrderBy(name string, orderByType string) *Generator {
+	if err := ValidateIdentifier(name); err != nil {
+		s.setErr(err)
+		return s
+	}
+	t, err := ValidateOrderByType(orderByType)
+	if err != nil {
+		s.setErr(err)
+		return s
+	}
 	if s.orderBy == nil {
-		s.orderBy = make([]string, 0)
+		s.orderBy = make([]orderBy, 0)
 	}
-	s.orderBy = append(s.orderBy, name+" "+orderByType)
+	s.orderBy = append(s.orderBy, orderBy{name: name, orderByType: t})
 	return s
 }
 
+// AddAllowedOrderBy 按白名单排序，key 一般来自接口参数，不在白名单中时生成sql会报错
+func (s *Generator) AddAllowedOrderBy(allow AllowList, key string, orderByType string) *Generator {
+	column, err := allow.Column(key)
+	if err != nil {
+		s.setErr(err)
+		return s
+	}
+	return s.AddOrderBy(column, orderByType)
+}
+
 func (s *Generator) GroupBy(groupBy []string) *Generator {
 	s.groupBy = groupBy
 	return s
 }
+
+// AddGroupBy 追加分组字段，tableName 为空时不加表名，可以按查询字段的别名分组
 func (s *Generator) AddGroupBy(tableName, name string) *Generator {
 	if s.groupBy == nil {
 		s.groupBy = make([]string, 0)
 	}
+	if tableName == "" {
+		s.groupBy = append(s.groupBy, name)
+		return s
+	}
 	s.groupBy = append(s.groupBy, tableName+"."+name)
 	return s
 }
 
+// offset 分页的起始位置，设置了页码时按页码计算，生成sql时不修改 pageStart
+func (s *Generator) offset() int {
+	if s.pageNum > 0 {
+		return (s.pageNum - 1) * s.pageSize
+	}
+	return s.pageStart
+}
+
+// queryTable 查询条件默认使用的表名，有别名时使用别名
+func (s *Generator) queryTable() string {
+	if s.tableAlias != "" {
+		return s.tableAlias
+	}
+	return s.tableName
+}
+
+// writeResult 查询的字段，合法的字段名按方言加引号，其余(函数、别名等)原样输出，返回表达式的参数
+func (s *Generator) writeResult(sql *bytes.Buffer, prepare bool) ([]any, error) {
+	columns := make([]string, 0, len(s.dateGroups)+len(s.columns)+len(s.resultExprs))
+	params := make([]any, 0)
+	for _, group := range s.dateGroups {
+		column, err := s.dateGroupSource(group)
+		if err != nil {
+			return nil, err
+		}
+		columns = append(columns, column+" "+group.alias)
+	}
+	for _, column := range s.columns {
+		if quoted, err := s.dialect.identifier(column); err == nil {
+			column = quoted
+		}
+		columns = append(columns, column)
+	}
+	for _, expr := range s.resultExprs {
+		column, param, err := expr.Source(s.dialect, s.queryTable(), prepare)
+		if err != nil {
+			return nil, err
+		}
+		if expr.alias != "" {
+			alias, err := s.dialect.identifier(expr.alias)
+			if err != nil {
+				return nil, err
+			}
+			column += " as " + alias
+		}
+		columns = append(columns, column)
+		params = append(params, param...)
+	}
+	sql.WriteString(strings.Join(columns, ","))
+	return params, nil
+}
+
+func (s *Generator) writeFrom(sql *bytes.Buffer) error {
+	table, err := s.dialect.identifier(s.physicalTable())
+	if err != nil {
+		return err
+	}
+	sql.WriteString(" from  " + table + "")
+
+	if s.tableAlias != "" || s.shardTable != "" {
+		alias, err := s.dialect.identifier(s.queryTable())
+		if err != nil {
+			return err
+		}
+		sql.WriteString(" " + alias + " ")
+	}
+	return nil
+}
+
+func (s *Generator) writeJoins(sql *bytes.Buffer, prepare bool) ([]any, error) {
+	params := make([]any, 0)
+	for _, join := range s.joins {
+		var scopes []Query
+		if join.subquery == nil {
+			var err error
+			if scopes, err = s.scopes(join.tableName, join.table()); err != nil {
+				return nil, err
+			}
+		}
+		source, param, err := join.source(s, scopes, prepare)
+		if err != nil {
+			return nil, err
+		}
+		sql.WriteString(source)
+		params = append(params, param...)
+	}
+	return params, nil
+}
+
+// writeWhere 多个条件之间用 or 连接，scopes 为软删除等自动附加的条件，有 scopes 时前面的条件加上括号后再用 and 连接
+// 返回实际生成的条件个数，不包括 scopes
+func (s *Generator) writeWhere(sql *bytes.Buffer, table string, scopes []Query, prepare bool) ([]any, int, error) {
+	params := make([]any, 0)
+	sources := make([]string, 0, len(s.querys))
+	for _, query := range s.querys {
+		source, param, err := querySource(query, s.dialect, table, prepare)
+		if err != nil {
+			return nil, 0, err
+		}
+		if source == "" {
+			continue
+		}
+		sources = append(sources, " "+source+" ")
+		params = append(params, param...)
+	}
+	n := len(sources)
+	if n == 0 && len(scopes) == 0 {
+		return params, 0, nil
+	}
+	sql.WriteString(" where   ")
+	if n > 0 && len(scopes) > 0 {
+		sql.WriteString("(" + strings.Join(sources, " or ") + ")")
+	} else {
+		sql.WriteString(strings.Join(sources, " or "))
+	}
+	for i, scope := range scopes {
+		source, param, err := querySource(scope, s.dialect, table, prepare)
+		if err != nil {
+			return nil, 0, err
+		}
+		if n > 0 || i > 0 {
+			sql.WriteString(" and")
+		}
+		sql.WriteString(" " + source + " ")
+		params = append(params, param...)
+	}
+	return params, n, nil
+}
+
+// writeOrderBy 排序，table 为表达式中字段默认的表名，返回表达式的参数
+func (s *Generator) writeOrderBy(sql *bytes.Buffer, table string, prepare bool) ([]any, error) {
+	if len(s.orderBy) == 0 {
+		return nil, nil
+	}
+	params := make([]any, 0)
+	sql.WriteString(" order by   ")
+	for n, v := range s.orderBy {
+		if n != 0 {
+			sql.WriteString(", ")
+		}
+		param, err := v.write(sql, s.dialect, table, prepare)
+		if err != nil {
+			return nil, err
+		}
+		params = append(params, param...)
+	}
+	return params, nil
+}
+
+// writeLimit 分页，返回分页的参数
+func (s *Generator) writeLimit(sql *bytes.Buffer, prepare bool) []any {
+	if s.pageSize <= 0 {
+		return nil
+	}
+	pageStart := s.offset()
+	if prepare {
+		sql.WriteString(fmt.Sprintf(" limit %s,%s", PLACE_HOLDER_GO, PLACE_HOLDER_GO))
+	} else {
+		sql.WriteString(fmt.Sprintf(" limit %d,%d", pageStart, s.pageSize))
+	}
+	return []any{pageStart, s.pageSize}
+}
+
+// writeGuard 更新、删除的条件检查和 order by、limit，返回排序表达式的参数
+func (s *Generator) writeGuard(sql *bytes.Buffer, n int, prepare bool) ([]any, error) {
+	if n == 0 && !s.allowFull {
+		return nil, errors.New("update or delete without where condition, call AllowFullTable() if it is intended")
+	}
+	if len(s.orderBy) == 0 && s.pageSize <= 0 {
+		return nil, nil
+	}
+	if s.dialect.isPostgres() {
+		return nil, errors.New("order by and limit in update or delete are not supported by postgres")
+	}
+	if s.offset() > 0 {
+		return nil, errors.New("update or delete does not support offset")
+	}
+	params, err := s.writeOrderBy(sql, s.physicalTable(), prepare)
+	if err != nil {
+		return nil, err
+	}
+	if s.pageSize > 0 {
+		sql.WriteString(fmt.Sprintf(" limit %d", s.pageSize))
+	}
+	return params, nil
+}
+
+// CountSql 统计sql，分表时在每张分表上统计后求和，设置了查询字段(如 group by 统计)时每张分表返回各自的结果，需要调用方相加
 func (s *Generator) CountSql(prepare bool) (string, []any, error) {
+	return s.checked(s.countSql, prepare)
+}
+
+func (s *Generator) countSql(prepare bool) (string, []any, error) {
+	if s.err != nil {
+		return "", nil, s.err
+	}
 	if s.tableName == "" {
 		return "", nil, errors.New("tableName cannot be empty")
 	}
+	tables, err := s.shardTables()
+	if err != nil {
+		return "", nil, err
+	}
+	if len(tables) == 1 {
+		return s.onShard(tables[0]).CountSql(prepare)
+	}
+	if len(tables) > 1 {
+		return s.shardCountSql(tables, prepare)
+	}
 	params := make([]any, 0, 10)
 	var sql bytes.Buffer
 	sql.WriteString("select ")
 
-	if s.columns == nil {
+	if s.columns == nil && s.resultExprs == nil {
 		sql.WriteString(" count(*) count  ")
 	} else {
-		sql.WriteString(strings.Join(s.columns, ","))
+		param, err := s.writeResult(&sql, prepare)
+		if err != nil {
+			return "", nil, err
+		}
+		params = append(params, param...)
 	}
 
-	sql.WriteString(" from  " + s.tableName + "")
-
-	if s.tableAlias != "" {
-		sql.WriteString(" " + s.tableAlias + " ")
+	if err := s.writeFrom(&sql); err != nil {
+		return "", nil, err
 	}
 
-	if s.joins != nil && len(s.joins) > 0 {
-		for _, join := range s.joins {
-			sql.WriteString(fmt.Sprintf(" %v %v on %v", join.joinType, join.tableName, join.condition))
-			for i, query := range join.querys {
-				if i == 0 {
-					sql.WriteString(" and ")
-				} else {
-					sql.WriteString(" or ")
-				}
-				source, param, _ := query.Source(join.tableName, prepare)
-				sql.WriteString(" " + source + " ")
-				params = append(params, param...)
-			}
-		}
+	param, err := s.writeJoins(&sql, prepare)
+	if err != nil {
+		return "", nil, err
 	}
+	params = append(params, param...)
 
-	if s.querys != nil && len(s.querys) > 0 {
-		var source string
-		var param []any
-		n := 0
-		table := s.tableName
-		if s.tableAlias != "" {
-			table = s.tableAlias
-		}
-		for _, query := range s.querys {
-			source, param, _ = query.Source(table, prepare)
-			if source == "" {
-				continue
-			}
-			if n == 0 {
-				sql.WriteString(" where   ")
-			} else {
-				sql.WriteString(" or ")
-			}
-			sql.WriteString(" " + source + " ")
-			params = append(params, param...)
-			n = n + 1
-		}
+	scopes, err := s.scopes(s.tableName, s.queryTable())
+	if err != nil {
+		return "", nil, err
 	}
+	param, _, err = s.writeWhere(&sql, s.queryTable(), scopes, prepare)
+	if err != nil {
+		return "", nil, err
+	}
+	params = append(params, param...)
 
 	return sql.String(), params, nil
 }
 
 func (s *Generator) SelectSql(prepare bool) (string, []any, error) {
+	return s.checked(s.selectSql, prepare)
+}
+
+func (s *Generator) selectSql(prepare bool) (string, []any, error) {
+	if s.err != nil {
+		return "", nil, s.err
+	}
 	if s.tableName == "" {
 		return "", nil, errors.New("tableName cannot be empty")
 	}
+	tables, err := s.shardTables()
+	if err != nil {
+		return "", nil, err
+	}
+	if len(tables) == 1 {
+		return s.onShard(tables[0]).SelectSql(prepare)
+	}
+	if len(tables) > 1 {
+		return s.shardSelectSql(tables, prepare)
+	}
 	params := make([]any, 0)
 	var sql bytes.Buffer
 	sql.WriteString("select ")
-	if s.columns == nil {
+	if s.columns == nil && s.dateGroups == nil && s.resultExprs == nil {
 		sql.WriteString(" * ")
 	} else {
-		sql.WriteString(strings.Join(s.columns, ","))
+		param, err := s.writeResult(&sql, prepare)
+		if err != nil {
+			return "", nil, err
+		}
+		params = append(params, param...)
 	}
-	sql.WriteString(" from  " + s.tableName + "")
 
-	if s.tableAlias != "" {
-		sql.WriteString(" " + s.tableAlias + " ")
+	if err := s.writeFrom(&sql); err != nil {
+		return "", nil, err
 	}
 
-	if s.joins != nil && len(s.joins) > 0 {
-		for _, join := range s.joins {
-			sql.WriteString(fmt.Sprintf(" %v %v on %v", join.joinType, join.tableName, join.condition))
-			for i, query := range join.querys {
-				if i == 0 {
-					sql.WriteString(" and ")
-				} else {
-					sql.WriteString(" or ")
-				}
-				source, param, _ := query.Source(join.tableName, prepare)
-				sql.WriteString(" " + source + " ")
-				params = append(params, param...)
-			}
-		}
+	param, err := s.writeJoins(&sql, prepare)
+	if err != nil {
+		return "", nil, err
 	}
+	params = append(params, param...)
 
-	if s.querys != nil && len(s.querys) > 0 {
-		var source string
-		var param []any
-		n := 0
-		table := s.tableName
-		if s.tableAlias != "" {
-			table = s.tableAlias
-		}
-		for _, query := range s.querys {
-			source, param, _ = query.Source(table, prepare)
-			if source == "" {
-				continue
-			}
-			if n == 0 {
-				sql.WriteString(" where   ")
-			} else {
-				sql.WriteString(" or ")
-			}
-			sql.WriteString(" " + source + " ")
-			params = append(params, param...)
-			n = n + 1
-		}
+	scopes, err := s.scopes(s.tableName, s.queryTable())
+	if err != nil {
+		return "", nil, err
+	}
+	param, _, err = s.writeWhere(&sql, s.queryTable(), scopes, prepare)
+	if err != nil {
+		return "", nil, err
 	}
-	if s.groupBy != nil && len(s.groupBy) > 0 {
+	params = append(params, param...)
+
+	if len(s.groupBy) > 0 || len(s.dateGroups) > 0 || len(s.groupExprs) > 0 {
 		sql.WriteString(" group by   ")
-		for n, v := range s.groupBy {
-			if n != 0 {
-				sql.WriteString(", ")
+		columns := make([]string, 0, len(s.dateGroups)+len(s.groupBy)+len(s.groupExprs))
+		for _, group := range s.dateGroups {
+			column, err := s.dateGroupSource(group)
+			if err != nil {
+				return "", nil, err
 			}
-			sql.WriteString(v)
+			columns = append(columns, column)
 		}
-	}
-	if s.orderBy != nil && len(s.orderBy) > 0 {
-		sql.WriteString(" order by   ")
-		for n, v := range s.orderBy {
-			if n != 0 {
-				sql.WriteString(", ")
+		for _, v := range s.groupBy {
+			column, err := s.dialect.identifier(v)
+			if err != nil {
+				return "", nil, err
 			}
-			sql.WriteString(v)
+			columns = append(columns, column)
 		}
-	}
-	if s.pageSize > 0 {
-		if s.pageNum > 0 {
-			s.pageStart = (s.pageNum - 1) * s.pageSize
-		}
-		params = append(params, s.pageStart, s.pageSize)
-		if prepare {
-			sql.WriteString(fmt.Sprintf(" limit %s,%s", PLACE_HOLDER_GO, PLACE_HOLDER_GO))
-		} else {
-			sql.WriteString(fmt.Sprintf(" limit %d,%d", s.pageStart, s.pageSize))
+		for _, expr := range s.groupExprs {
+			column, param, err := expr.Source(s.dialect, s.queryTable(), prepare)
+			if err != nil {
+				return "", nil, err
+			}
+			columns = append(columns, column)
+			params = append(params, param...)
 		}
+		sql.WriteString(strings.Join(columns, ", "))
+	}
+	param, err = s.writeOrderBy(&sql, s.queryTable(), prepare)
+	if err != nil {
+		return "", nil, err
 	}
+	params = append(params, param...)
+	params = append(params, s.writeLimit(&sql, prepare)...)
 
 	return sql.String(), params, nil
 }
 
+// DeleteSql 多个条件之间用 or 连接，没有条件时需要先调用 AllowFullTable
 func (s *Generator) DeleteSql(prepare bool) (string, []any, error) {
+	return s.checked(s.deleteSql, prepare)
+}
+
+func (s *Generator) deleteSql(prepare bool) (string, []any, error) {
+	if s.err != nil {
+		return "", nil, s.err
+	}
 	if s.tableName == "" {
 		return "", nil, errors.New("tableName cannot be empty")
 	}
-	if s.querys == nil || len(s.querys) != 1 {
-		return "", nil, errors.New("the querys size must be 1")
+	if shard, err := s.writeShard(); err != nil || shard != nil {
+		if err != nil {
+			return "", nil, err
+		}
+		return shard.DeleteSql(prepare)
+	}
+	table, err := s.dialect.identifier(s.physicalTable())
+	if err != nil {
+		return "", nil, err
+	}
+	if column := s.softDeleteColumn(s.tableName); column != "" && !s.forceDelete {
+		return s.softDeleteSql(table, column, prepare)
+	}
+	scopes, err := s.writeScopes()
+	if err != nil {
+		return "", nil, err
 	}
-	params := make([]any, 0, 10)
 	var sql bytes.Buffer
-	sql.WriteString("delete from " + s.tableName + " ")
+	sql.WriteString("delete from " + table + " ")
 
-	sql.WriteString(" where   ")
-	for i, query := range s.querys {
-		if i != 0 {
-			sql.WriteString(" or ")
-		}
-		source, param, _ := query.Source(s.tableName, prepare)
-		sql.WriteString(" " + source + " ")
-		params = append(params, param...)
+	params, n, err := s.writeWhere(&sql, s.physicalTable(), scopes, prepare)
+	if err != nil {
+		return "", nil, err
 	}
-
-	return sql.String(), params, nil
+	param, err := s.writeGuard(&sql, n, prepare)
+	if err != nil {
+		return "", nil, err
+	}
+	return sql.String(), append(params, param...), nil
 }
 func (s *Generator) InsertSql(prepare bool) (string, []any, error) {
+	return s.checked(s.insertSql, prepare)
+}
 
+func (s *Generator) insertSql(prepare bool) (string, []any, error) {
+	if s.err != nil {
+		return "", nil, s.err
+	}
 	if s.tableName == "" {
 		return "", nil, errors.New("tableName  cannot be empty")
 	}
+	if s.shardStrategy() != nil && s.shardTable == "" {
+		sqls, err := s.InsertShardSqls(prepare)
+		if err != nil {
+			return "", nil, err
+		}
+		if len(sqls) > 1 {
+			return "", nil, fmt.Errorf("insert into %s spans %d shards, use InsertShardSqls", s.tableName, len(sqls))
+		}
+		return sqls[0].Sql, sqls[0].Params, nil
+	}
+	table, err := s.dialect.identifier(s.physicalTable())
+	if err != nil {
+		return "", nil, err
+	}
+	insert, inserts, err := s.tenantInserts()
+	if err != nil {
+		return "", nil, err
+	}
 	n := 0
 	params := make([]any, 0)
 	fields := make([]string, 0)
 	var sql bytes.Buffer
-	sql.WriteString("insert into " + s.tableName + " ")
+	sql.WriteString("insert into " + table + " ")
 	sql.WriteString("(")
-	if s.inserts != nil && len(s.inserts) > 0 {
+	if inserts != nil && len(inserts) > 0 {
 		//把所有要修改的字段提取出来
 
-		for field, _ := range s.inserts[0] {
+		for field, _ := range inserts[0] {
 			fields = append(fields, field)
 		}
 
@@ -320,13 +671,17 @@ func (s *Generator) InsertSql(prepare bool) (string, []any, error) {
 			if n != 0 {
 				sql.WriteString(",")
 			}
-			sql.WriteString(" " + field + " ")
+			column, err := s.dialect.identifier(field)
+			if err != nil {
+				return "", nil, err
+			}
+			sql.WriteString(" " + column + " ")
 			n++
 		}
 		sql.WriteString(") values")
 		n = 0
 
-		for _, maps := range s.inserts {
+		for _, maps := range inserts {
 			if n != 0 {
 				sql.WriteString(",")
 			}
@@ -340,7 +695,7 @@ func (s *Generator) InsertSql(prepare bool) (string, []any, error) {
 				if prepare {
 					sql.WriteString(fmt.Sprintf(" %s ", PLACE_HOLDER_GO))
 				} else {
-					sql.WriteString(fmt.Sprintf(" '%v' ", maps[field]))
+					sql.WriteString(fmt.Sprintf(" %s ", literal(s.dialect, maps[field])))
 				}
 				m++
 			}
@@ -348,14 +703,18 @@ func (s *Generator) InsertSql(prepare bool) (string, []any, error) {
 			n++
 		}
 	} else {
-		for field, _ := range s.insert {
+		for field, _ := range insert {
 			fields = append(fields, field)
 		}
 		for _, field := range fields {
 			if n != 0 {
 				sql.WriteString(",")
 			}
-			sql.WriteString(" " + field + " ")
+			column, err := s.dialect.identifier(field)
+			if err != nil {
+				return "", nil, err
+			}
+			sql.WriteString(" " + column + " ")
 			n++
 		}
 		sql.WriteString(") values")
@@ -366,11 +725,11 @@ func (s *Generator) InsertSql(prepare bool) (string, []any, error) {
 			if m != 0 {
 				sql.WriteString(",")
 			}
-			params = append(params, s.insert[field])
+			params = append(params, insert[field])
 			if prepare {
 				sql.WriteString(fmt.Sprintf(" %s ", PLACE_HOLDER_GO))
 			} else {
-				sql.WriteString(fmt.Sprintf(" '%v' ", s.insert[field]))
+				sql.WriteString(fmt.Sprintf(" %s ", literal(s.dialect, insert[field])))
 			}
 			m++
 		}
@@ -380,25 +739,53 @@ func (s *Generator) InsertSql(prepare bool) (string, []any, error) {
 	return sql.String(), params, nil
 }
 
+// UpdateSql 多个条件之间用 or 连接，没有条件时需要先调用 AllowFullTable
 func (s *Generator) UpdateSql(prepare bool) (string, []any, error) {
+	return s.checked(s.updateSql, prepare)
+}
 
+func (s *Generator) updateSql(prepare bool) (string, []any, error) {
+	if s.err != nil {
+		return "", nil, s.err
+	}
 	if s.tableName == "" {
 		return "", nil, errors.New("tableName  cannot be empty")
 	}
 
-	if s.querys == nil || len(s.querys) != 1 {
-		return "", nil, errors.New("the querys size must be 1")
+	if len(s.update) == 0 && len(s.updates) == 0 {
+		return "", nil, errors.New("update cannot be empty")
+	}
+	if err := s.checkTenantUpdate(); err != nil {
+		return "", nil, err
+	}
+	if shard, err := s.writeShard(); err != nil || shard != nil {
+		if err != nil {
+			return "", nil, err
+		}
+		return shard.UpdateSql(prepare)
+	}
+	table, err := s.dialect.identifier(s.physicalTable())
+	if err != nil {
+		return "", nil, err
 	}
 
 	params := make([]any, 0, 10)
 	var sql bytes.Buffer
-	sql.WriteString("update " + s.tableName + " set ")
+	sql.WriteString("update " + table + " set ")
 	n := 0
 	if s.updates != nil && len(s.updates) > 0 { //批量更新
 
-		if s.primary == "" {
+		if len(s.primary) == 0 {
 			return "", nil, errors.New("primary cannot be empty")
 		}
+		primarys := make([]string, 0, len(s.primary))
+		for _, name := range s.primary {
+			primary, err := s.dialect.identifier(name)
+			if err != nil {
+				return "", nil, err
+			}
+			primarys = append(primarys, primary)
+		}
 
 		//把所有要修改的字段提取出来
 		fields := make(map[string]string)
@@ -412,17 +799,48 @@ func (s *Generator) UpdateSql(prepare bool) (string, []any, error) {
 			if n != 0 {
 				sql.WriteString(",")
 			}
-			sql.WriteString(fmt.Sprintf("%v = CASE %v", field, s.primary))
+			column, err := s.dialect.identifier(field)
+			if err != nil {
+				return "", nil, err
+			}
+			if len(primarys) == 1 {
+				sql.WriteString(fmt.Sprintf("%v = CASE %v", column, primarys[0]))
+			} else {
+				sql.WriteString(fmt.Sprintf("%v = CASE", column))
+			}
 			for _, setMap := range s.updates {
 				v, ok := setMap[field]
 				if !ok {
 					continue
 				}
-				params = append(params, setMap[s.primary], v)
+				if len(primarys) == 1 {
+					params = append(params, setMap[s.primary[0]], v)
+					if prepare {
+						sql.WriteString(fmt.Sprintf(" WHEN %s THEN %s", PLACE_HOLDER_GO, PLACE_HOLDER_GO))
+					} else {
+						sql.WriteString(fmt.Sprintf(" WHEN %s THEN %s", literal(s.dialect, setMap[s.primary[0]]), literal(s.dialect, v)))
+					}
+					continue
+				}
+				//联合主键 CASE WHEN a = ? and b = ? THEN ?
+				conditions := make([]string, 0, len(primarys))
+				for i, name := range s.primary {
+					key, ok := setMap[name]
+					if !ok {
+						return "", nil, fmt.Errorf("update map missing primary %s", name)
+					}
+					params = append(params, key)
+					if prepare {
+						conditions = append(conditions, primarys[i]+" = "+PLACE_HOLDER_GO)
+					} else {
+						conditions = append(conditions, primarys[i]+" = "+literal(s.dialect, key))
+					}
+				}
+				params = append(params, v)
 				if prepare {
-					sql.WriteString(fmt.Sprintf(" WHEN %s THEN %s", PLACE_HOLDER_GO, PLACE_HOLDER_GO))
+					sql.WriteString(fmt.Sprintf(" WHEN %s THEN %s", strings.Join(conditions, " and "), PLACE_HOLDER_GO))
 				} else {
-					sql.WriteString(fmt.Sprintf(" WHEN '%v' THEN '%v'", setMap[s.primary], v))
+					sql.WriteString(fmt.Sprintf(" WHEN %s THEN %s", strings.Join(conditions, " and "), literal(s.dialect, v)))
 				}
 			}
 			sql.WriteString(" END ")
@@ -433,19 +851,68 @@ func (s *Generator) UpdateSql(prepare bool) (string, []any, error) {
 			if n != 0 {
 				sql.WriteString(",")
 			}
+			column, err := s.dialect.identifier(name)
+			if err != nil {
+				return "", nil, err
+			}
 			if prepare {
-				sql.WriteString(fmt.Sprintf("%v=%s", name, PLACE_HOLDER_GO))
+				sql.WriteString(fmt.Sprintf("%v=%s", column, PLACE_HOLDER_GO))
 			} else {
-				sql.WriteString(fmt.Sprintf("%v='%v'", name, value))
+				sql.WriteString(fmt.Sprintf("%v=%s", column, literal(s.dialect, value)))
 			}
 			params = append(params, value)
 			n++
 		}
 	}
 
-	source, param, _ := s.querys[0].Source(s.tableName, prepare)
-	sql.WriteString(" where " + source + " ")
+	scopes, err := s.writeScopes()
+	if err != nil {
+		return "", nil, err
+	}
+	param, n, err := s.writeWhere(&sql, s.physicalTable(), scopes, prepare)
+	if err != nil {
+		return "", nil, err
+	}
 	params = append(params, param...)
+	param, err = s.writeGuard(&sql, n, prepare)
+	if err != nil {
+		return "", nil, err
+	}
+	return sql.String(), append(params, param...), nil
+}
 
-	return sql.String(), params, nil
+// Pagination 当前的页码和每页条数，设置了 PageStart 时按 PageStart 计算页码
+func (s *Generator) Pagination() (int, int) {
+	if s.pageSize <= 0 {
+		return 0, 0
+	}
+	if s.pageNum > 0 {
+		return s.pageNum, s.pageSize
+	}
+	return s.pageStart/s.pageSize + 1, s.pageSize
+}
+
+// Offset 分页的起始行，设置了 PageNum 时按页码计算，否则为 PageStart
+func (s *Generator) Offset() int {
+	return s.offset()
+}
+
+// PageCountSql 分页查询对应的统计sql，去掉排序和分页，有 group by 或 distinct 时统计子查询的行数
+func (s *Generator) PageCountSql(prepare bool) (string, []any, error) {
+	c := s.Clone()
+	c.orderBy = nil
+	c.pageNum, c.pageStart, c.pageSize = 0, 0, 0
+	if len(c.groupBy) == 0 && len(c.dateGroups) == 0 && len(c.groupExprs) == 0 && !c.isDistinct() {
+		c.columns, c.resultExprs = nil, nil
+		return c.CountSql(prepare)
+	}
+	sql, params, err := c.SelectSql(prepare)
+	if err != nil {
+		return "", nil, err
+	}
+	return "select count(*) count from (" + sql + ") t", params, nil
+}
+
+func (s *Generator) isDistinct() bool {
+	return len(s.columns) > 0 && strings.HasPrefix(strings.ToLower(strings.TrimSpace(s.columns[0])), "distinct ")
 }
@@ -1,8 +1,17 @@
 package generator
 
 import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/url"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestGenerator_CountSql(t *testing.T) {
@@ -158,3 +167,1220 @@ func TestGenerator_InsertSql(t *testing.T) {
 	gen := NewGenerator().Table("user").Insert(f3)
 	fmt.Print(gen.InsertSql(false))
 }
+
+func TestGenerator_QuoteSql(t *testing.T) {
+	// select * from `order` where `order`.`user_id` = ? order by `order`.`id` desc
+	query := NewEqualQuery("user_id", 1000)
+	gen := NewGenerator().Table("order").Where(query).AddOrderBy("order.id", "DESC").Quote(true)
+	sql, params, err := gen.SelectSql(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(sql, "from  `order`") || !strings.Contains(sql, "`order`.`user_id` = ") || !strings.Contains(sql, "order by   `order`.`id` desc") {
+		t.Errorf("unexpected sql %s", sql)
+	}
+	if len(params) != 1 {
+		t.Errorf("unexpected params %v", params)
+	}
+
+	gen = NewGenerator().Table("order").Where(query).Dialect(DIALECT_POSTGRES).Quote(true)
+	sql, _, _ = gen.SelectSql(true)
+	if !strings.Contains(sql, `"order"."user_id" = `) {
+		t.Errorf("unexpected sql %s", sql)
+	}
+}
+
+func TestGenerator_InvalidIdentifier(t *testing.T) {
+	gens := []*Generator{
+		NewGenerator().Table("user").AddOrderBy("id;drop table user", "asc"),
+		NewGenerator().Table("user").AddOrderBy("id", "asc,(select 1)"),
+		NewGenerator().Table("user").OrderBy([]string{"id desc", "age desc limit 1"}),
+		NewGenerator().Table("user").Where(NewEqualQuery("id=1 or 1", 1)),
+		NewGenerator().Table("user").Join(NewJoin("order", INNER_JOIN).Condition("user", "id", "order", "user_id or 1=1")),
+	}
+	for _, gen := range gens {
+		if sql, _, err := gen.SelectSql(true); err == nil {
+			t.Errorf("expected error, got sql %s", sql)
+		}
+	}
+}
+
+func TestGenerator_AllowedOrderBy(t *testing.T) {
+	allow := AllowList{"created": "create_time", "age": "user.age"}
+	gen := NewGenerator().Table("user").AddAllowedOrderBy(allow, "created", "desc").AddAllowedOrderBy(allow, "age", "asc")
+	sql, _, err := gen.SelectSql(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(sql, "order by   create_time desc, user.age asc") {
+		t.Errorf("unexpected sql %s", sql)
+	}
+	gen = NewGenerator().Table("user").AddAllowedOrderBy(allow, "password", "desc")
+	if _, _, err := gen.SelectSql(true); err == nil {
+		t.Error("expected error for field not in allow list")
+	}
+}
+
+func TestQueryParser_Parse(t *testing.T) {
+	query := NewBoolQuery().And(
+		NewEqualQuery("status", 1),
+		NewInQuery("type", []any{1, 2}),
+		NewBetweenQuery("age", 10, 20),
+		NewNullQueryWithTable("user", "deleted_at"),
+		NewLikeQuery("name", "%lazyer%"),
+		NewFieldGreaterThanQueryWithTable("o", "amount", "user.balance"),
+	)
+	data, err := json.Marshal(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := ParseQuery(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, wantParams, _ := query.Source("user", true)
+	got, gotParams, _ := parsed.Source("user", true)
+	if want != got || fmt.Sprint(wantParams) != fmt.Sprint(gotParams) || !strings.Contains(got, "o.amount > user.balance") {
+		t.Errorf("want %s %v, got %s %v", want, wantParams, got, gotParams)
+	}
+	if got, _, _ = NewNode(query).Query().Source("user", true); got != want {
+		t.Errorf("want %s, got %s", want, got)
+	}
+
+	// 表达式不能转换成json，返回明确的错误而不是丢掉条件
+	if _, err := json.Marshal(NewBoolQuery().And(NewEqualQuery("status", 1), Col("age").Gt(18))); !errors.Is(err, ErrNotSerializable) {
+		t.Errorf("want ErrNotSerializable, got %v", err)
+	}
+	if _, err := json.Marshal(NewBoolQuery().Or(rawOrQuery("a = 1 or b = 2"))); !errors.Is(err, ErrNotSerializable) {
+		t.Errorf("want ErrNotSerializable, got %v", err)
+	}
+
+	parser := NewQueryParser().Allow(AllowList{"age": "user_age"}).MaxDepth(2).MaxValues(2)
+	parsed, err = parser.Parse([]byte(`{"bool":{"and":[{"gte":{"field":"age","value":18}}]}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sql, _, _ := parsed.Source("user", true); !strings.Contains(sql, "user.user_age >= ") {
+		t.Errorf("unexpected sql %s", sql)
+	}
+	invalids := []string{
+		`{"equal":{"field":"password","value":1}}`,
+		`{"in":{"field":"age","values":[1,2,3]}}`,
+		`{"bool":{"and":[{"bool":{"and":[{"equal":{"field":"age","value":1}}]}}]}}`,
+		`{"equal":{"field":"age","value":{"a":1}}}`,
+		`{"equal":{"field":"age"}}`,
+		`{"unknown":{"field":"age","value":1}}`,
+	}
+	for _, invalid := range invalids {
+		if _, err := parser.Parse([]byte(invalid)); err == nil {
+			t.Errorf("expected error for %s", invalid)
+		}
+	}
+}
+
+func TestValuesParser_Apply(t *testing.T) {
+	values, _ := url.ParseQuery("age_gte=20&status_in=1,2&name_like=foo&password=1&sort=-age,id&page=3&size=10")
+	parser := NewValuesParser(NewAllowList("age", "status", "name", "id")).Convert("age", IntConverter).Convert("status", IntConverter)
+	gen, err := parser.Apply(NewGenerator().Table("user"), values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sql, params, err := gen.SelectSql(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "select  *  from  user where    ( user.age >= ⒼⓄ  and user.name like ⒼⓄ escape '!'  and user.status in ( ⒼⓄ , ⒼⓄ) )  order by   age desc, id asc limit ⒼⓄ,ⒼⓄ"
+	if sql != want {
+		t.Errorf("want %s\ngot  %s", want, sql)
+	}
+	if fmt.Sprint(params) != "[20 %foo% 1 2 20 10]" {
+		t.Errorf("unexpected params %v", params)
+	}
+
+	values, _ = url.ParseQuery("name_like=%25a_&name_notlike=b%25")
+	gen, err = parser.Suffix("_notlike", DSL_NOT_LIKE).Apply(NewGenerator().Table("user"), values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sql, params, _ = gen.SelectSql(true)
+	if sql != "select  *  from  user where    ( user.name like ⒼⓄ escape '!'  and user.name not like ⒼⓄ escape '!' ) " || fmt.Sprint(params) != "[%!%a!_% %b!%%]" {
+		t.Errorf("unexpected sql %s %v", sql, params)
+	}
+	kinds := make([]string, 0)
+	gen.Walk(func(node *Node) bool {
+		kinds = append(kinds, node.Kind)
+		return true
+	})
+	if fmt.Sprint(kinds) != "[bool contains not_contains]" {
+		t.Errorf("not like should be visible to walk, got %v", kinds)
+	}
+
+	values, _ = url.ParseQuery("age_gte=abc")
+	if _, err := parser.Parse(values); err == nil {
+		t.Error("expected error for invalid int")
+	}
+	values, _ = url.ParseQuery("sort=password")
+	if _, err := parser.Apply(NewGenerator().Table("user"), values); err == nil {
+		t.Error("expected error for sort field not in allow list")
+	}
+}
+
+func TestGenerator_DebugString(t *testing.T) {
+	query := NewBoolQuery().And(NewEqualQuery("name", "O'Brien\\"), NewInQuery("id", []any{1, 2}), NewEqualQuery("vip", true))
+	gen := NewGenerator().Table("user").Where(query).AddOrderBy("id", "desc").PageNum(2).PageSize(10)
+	want := "select  *  from  user where    ( user.name = 'O''Brien\\\\'  and user.id in ( 1 , 2)  and user.vip = 1 )  order by   id desc limit 10,10"
+	if got := gen.DebugString(); got != want {
+		t.Errorf("want %s\ngot  %s", want, got)
+	}
+	sql, _, _ := gen.SelectSql(false)
+	if sql != want {
+		t.Errorf("want %s\ngot  %s", want, sql)
+	}
+
+	pretty := "select *\nfrom user\nwhere (user.name = 'O''Brien\\\\' and user.id in (1, 2) and user.vip = 1)\norder by id desc\nlimit 10, 10"
+	if got := gen.PrettyString(); got != pretty {
+		t.Errorf("want %s\ngot  %s", pretty, got)
+	}
+	if _, err := Interpolate("select * from user where id = "+PLACE_HOLDER_GO, nil, DIALECT_MYSQL); err == nil {
+		t.Error("expected error for params count mismatch")
+	}
+
+	// 删除需要指定语句类型
+	del := NewGenerator().Table("user").Where(NewEqualQuery("id", 1)).StatementKind(STATEMENT_DELETE)
+	if got := del.DebugString(); got != "delete from user  where    user.id = 1 " {
+		t.Errorf("unexpected sql %s", got)
+	}
+	if err := del.Validate(); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if err := NewGenerator().Table("user").StatementKind(STATEMENT_DELETE).Validate(); err == nil {
+		t.Error("want error for delete without condition")
+	}
+	parsed, _, _ := ParseSql("delete from user where id = ?", 1)
+	if got := parsed.DebugString(); got != "delete from user  where    user.id = 1 " {
+		t.Errorf("unexpected sql %s", got)
+	}
+	if got := NewGenerator().Table("user").StatementKind("drop").DebugString(); got != `error: invalid statement kind "drop"` {
+		t.Errorf("unexpected sql %s", got)
+	}
+}
+
+func TestGenerator_Clone(t *testing.T) {
+	boolQuery := NewBoolQuery().And(NewEqualQuery("status", 1))
+	base := NewGenerator().Table("user").Where(boolQuery).PageNum(2).PageSize(10)
+	want, _, _ := base.SelectSql(true)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			gen := base.Clone().Where(NewEqualQuery("id", i)).AddOrderBy("id", "desc")
+			gen.querys[0].(*BoolQuery).And(NewGreaterThanQuery("age", i))
+			if _, _, err := gen.SelectSql(true); err != nil {
+				t.Error(err)
+			}
+			if _, _, err := base.CountSql(true); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	got, params, _ := base.SelectSql(true)
+	if got != want || base.pageStart != 0 || fmt.Sprint(params) != "[1 10 10]" {
+		t.Errorf("base generator changed: %s %v", got, params)
+	}
+}
+
+func TestGenerator_Join(t *testing.T) {
+	// 自关联，多个 on 条件，附加条件 and / or
+	parent := NewJoin("user", LEFT_JOIN).Alias("parent").
+		Condition("parent", "id", "user", "parent_id").
+		On(NewFieldEqualQuery("parent.tenant_id", "user.tenant_id")).
+		Where(NewEqualQuery("status", 1)).
+		OrWhere(NewEqualQuery("type", 1), NewEqualQuery("type", 2))
+	// 派生表
+	orders := NewGenerator().Result("user_id", "count(*) num").Table("order").Where(NewGreaterThanQuery("amount", 100)).AddGroupBy("order", "user_id")
+	sub := NewSubqueryJoin(orders, "o", INNER_JOIN).Condition("o", "user_id", "user", "id")
+	gen := NewGenerator().Result("user.id", "parent.name", "o.num").Table("user").Join(parent, sub).Where(NewEqualQuery("id", 1000))
+	sql, params, err := gen.SelectSql(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "select user.id,parent.name,o.num from  user" +
+		" left join user parent on parent.id = user.parent_id and parent.tenant_id = user.tenant_id and parent.status = ⒼⓄ and ( parent.type = ⒼⓄ  or parent.type = ⒼⓄ )" +
+		" inner join (select user_id,count(*) num from  order where    order.amount > ⒼⓄ  group by   order.user_id) o on o.user_id = user.id" +
+		" where    user.id = ⒼⓄ "
+	if sql != want {
+		t.Errorf("want %s\ngot  %s", want, sql)
+	}
+	if fmt.Sprint(params) != "[1 1 2 100 1000]" {
+		t.Errorf("unexpected params %v", params)
+	}
+
+	gen = NewGenerator().Table("user").Join(NewJoin("profile", INNER_JOIN).Using("user_id"), NewJoin("dict", CROSS_JOIN))
+	if sql, _, _ := gen.SelectSql(true); sql != "select  *  from  user inner join profile using (user_id) cross join dict" {
+		t.Errorf("unexpected sql %s", sql)
+	}
+
+	gen = NewGenerator().Table("user").Join(NewJoin("order", FULL_JOIN).Condition("order", "user_id", "user", "id"))
+	if _, _, err := gen.SelectSql(true); err == nil {
+		t.Error("expected error for full join on mysql")
+	}
+	if _, _, err := gen.Dialect(DIALECT_POSTGRES).SelectSql(true); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestQuery_WithTable(t *testing.T) {
+	join := NewJoin("order", LEFT_JOIN).Alias("o").Condition("o", "user_id", "u", "id")
+	query := NewBoolQuery().And(
+		NewGreaterThanQueryWithTable("o", "amount", 100),
+		NewInQuery("o.status", []any{1, 2}),
+		NewLikeQueryWithTable("u", "name", "%lazyer%"),
+		NewBetweenQuery("age", 10, 20),
+		NewFieldGreaterThanQuery("o.create_time", "create_time"),
+		NewFieldLessThanOrEqualQuery("update_time", "o.update_time"),
+	)
+	gen := NewGenerator().Table("user").TableAlias("u").Join(join).Where(query)
+	sql, _, err := gen.SelectSql(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "select  *  from  user u  left join order o on o.user_id = u.id where    ( o.amount > ⒼⓄ  and o.status in ( ⒼⓄ , ⒼⓄ)  and u.name like ⒼⓄ  and u.age between ⒼⓄ and ⒼⓄ  and o.create_time > u.create_time  and u.update_time <= o.update_time ) "
+	if sql != want {
+		t.Errorf("want %s\ngot  %s", want, sql)
+	}
+	data, _ := json.Marshal(query)
+	parsed, err := ParseQuery(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _, _ := parsed.Source("u", true); strings.TrimSpace("("+strings.SplitN(want, "where    (", 2)[1]) != got {
+		t.Errorf("unexpected parsed query %s", got)
+	}
+}
+
+func TestGenerator_GuardedWrite(t *testing.T) {
+	gen := NewGenerator().Table("user").Where(NewBoolQuery())
+	if _, _, err := gen.DeleteSql(true); err == nil {
+		t.Error("expected error for delete without condition")
+	}
+	if sql, _, err := gen.AllowFullTable().DeleteSql(true); err != nil || sql != "delete from user " {
+		t.Errorf("unexpected sql %s %v", sql, err)
+	}
+
+	gen = NewGenerator().Table("log").Where(NewLessThanQuery("create_time", "2024-01-01"), NewEqualQuery("level", "debug")).AddOrderBy("id", "asc").Limit(1000)
+	sql, params, err := gen.DeleteSql(true)
+	want := "delete from log  where    log.create_time < ⒼⓄ  or  log.level = ⒼⓄ  order by   id asc limit 1000"
+	if err != nil || sql != want || len(params) != 2 {
+		t.Errorf("want %s\ngot  %s %v %v", want, sql, params, err)
+	}
+	if _, _, err := gen.Dialect(DIALECT_POSTGRES).DeleteSql(true); err == nil {
+		t.Error("expected error for limit on postgres")
+	}
+
+	gen = NewGenerator().Table("user").Update(map[string]any{"status": 0}).Where(NewEqualQuery("status", 1)).Limit(100)
+	sql, _, err = gen.UpdateSql(true)
+	if err != nil || sql != "update user set status=ⒼⓄ where    user.status = ⒼⓄ  limit 100" {
+		t.Errorf("unexpected sql %s %v", sql, err)
+	}
+	if _, _, err := NewGenerator().Table("user").Update(map[string]any{"status": 0}).UpdateSql(true); err == nil {
+		t.Error("expected error for update without condition")
+	}
+}
+
+func TestGenerator_SoftDelete(t *testing.T) {
+	RegisterSoftDelete("soft_user", "deleted_at")
+	RegisterSoftDelete("soft_order", "deleted_at")
+
+	join := NewJoin("soft_order", LEFT_JOIN).Alias("o").Condition("o", "user_id", "soft_user", "id")
+	gen := NewGenerator().Table("soft_user").Join(join).Where(NewEqualQuery("id", 1), NewEqualQuery("id", 2))
+	sql, _, _ := gen.SelectSql(true)
+	want := "select  *  from  soft_user left join soft_order o on (o.user_id = soft_user.id) and o.deleted_at is null where   ( soft_user.id = ⒼⓄ  or  soft_user.id = ⒼⓄ ) and soft_user.deleted_at is null "
+	if sql != want {
+		t.Errorf("want %s\ngot  %s", want, sql)
+	}
+	sql, _, _ = NewGenerator().Table("soft_user").CountSql(true)
+	if sql != "select  count(*) count   from  soft_user where    soft_user.deleted_at is null " {
+		t.Errorf("unexpected sql %s", sql)
+	}
+	sql, _, _ = NewGenerator().Table("soft_user").OnlyTrashed().SelectSql(true)
+	if sql != "select  *  from  soft_user where    soft_user.deleted_at is not null " {
+		t.Errorf("unexpected sql %s", sql)
+	}
+	sql, _, _ = NewGenerator().Table("soft_user").WithTrashed().SelectSql(true)
+	if sql != "select  *  from  soft_user" {
+		t.Errorf("unexpected sql %s", sql)
+	}
+
+	gen = NewGenerator().Table("soft_user").Where(NewEqualQuery("id", 1))
+	sql, params, _ := gen.DeleteSql(true)
+	if sql != "update soft_user set deleted_at=now() where   ( soft_user.id = ⒼⓄ ) and soft_user.deleted_at is null " || len(params) != 1 {
+		t.Errorf("unexpected sql %s", sql)
+	}
+	sql, _, _ = gen.ForceDelete().DeleteSql(true)
+	if sql != "delete from soft_user  where    soft_user.id = ⒼⓄ " {
+		t.Errorf("unexpected sql %s", sql)
+	}
+	sql, _, _ = NewGenerator().Table("user").SoftDelete("remove_time").SelectSql(true)
+	if sql != "select  *  from  user where    user.remove_time is null " {
+		t.Errorf("unexpected sql %s", sql)
+	}
+
+	// 只有一个含 or 的条件时也加上括号，不能查到、删除已软删除的数据
+	join = NewJoin("soft_order", LEFT_JOIN).Alias("o").On(rawOrQuery("o.user_id = soft_user.id or o.buyer_id = soft_user.id"))
+	gen = NewGenerator().Table("soft_user").Join(join).Where(rawOrQuery("soft_user.id = 1 or soft_user.id = 2"))
+	sql, _, _ = gen.SelectSql(true)
+	want = "select  *  from  soft_user left join soft_order o on (o.user_id = soft_user.id or o.buyer_id = soft_user.id) and o.deleted_at is null where   ( soft_user.id = 1 or soft_user.id = 2 ) and soft_user.deleted_at is null "
+	if sql != want {
+		t.Errorf("want %s\ngot  %s", want, sql)
+	}
+	sql, _, _ = NewGenerator().Table("soft_user").Where(rawOrQuery("id = 1 or id = 2")).DeleteSql(true)
+	if sql != "update soft_user set deleted_at=now() where   ( id = 1 or id = 2 ) and soft_user.deleted_at is null " {
+		t.Errorf("unexpected sql %s", sql)
+	}
+}
+
+func TestGenerator_Tenant(t *testing.T) {
+	RegisterTenant("tenant_user", "tenant_id")
+	RegisterTenant("tenant_order", "tenant_id")
+
+	join := NewJoin("tenant_order", LEFT_JOIN).Alias("o").Condition("o", "user_id", "tenant_user", "id")
+	gen := NewGenerator().Table("tenant_user").Join(join).Where(NewEqualQuery("id", 1)).Tenant(7)
+	sql, params, _ := gen.SelectSql(true)
+	want := "select  *  from  tenant_user left join tenant_order o on (o.user_id = tenant_user.id) and o.tenant_id = ⒼⓄ where   ( tenant_user.id = ⒼⓄ ) and tenant_user.tenant_id = ⒼⓄ "
+	if sql != want || fmt.Sprint(params) != "[7 1 7]" {
+		t.Errorf("want %s\ngot  %s %v", want, sql, params)
+	}
+	sql, params, _ = NewGenerator().Table("tenant_user").WithContext(WithTenant(context.Background(), 7)).CountSql(true)
+	if sql != "select  count(*) count   from  tenant_user where    tenant_user.tenant_id = ⒼⓄ " || fmt.Sprint(params) != "[7]" {
+		t.Errorf("unexpected sql %s %v", sql, params)
+	}
+	sql, params, _ = NewGenerator().Table("tenant_user").Tenant(7).Where(NewEqualQuery("id", 1)).Update(map[string]any{"name": "foo"}).UpdateSql(true)
+	if sql != "update tenant_user set name=ⒼⓄ where   ( tenant_user.id = ⒼⓄ ) and tenant_user.tenant_id = ⒼⓄ " || fmt.Sprint(params) != "[foo 1 7]" {
+		t.Errorf("unexpected sql %s %v", sql, params)
+	}
+	if _, _, err := NewGenerator().Table("tenant_user").Tenant(7).Where(NewEqualQuery("id", 1)).Update(map[string]any{"tenant_id": 7}).UpdateSql(true); err != nil {
+		t.Errorf("update with the same tenant should pass: %v", err)
+	}
+	sql, _, _ = NewGenerator().Table("tenant_user").Tenant(7).Where(NewEqualQuery("id", 1)).DeleteSql(true)
+	if sql != "delete from tenant_user  where   ( tenant_user.id = ⒼⓄ ) and tenant_user.tenant_id = ⒼⓄ " {
+		t.Errorf("unexpected sql %s", sql)
+	}
+	insert := map[string]any{"name": "foo"}
+	sql = NewGenerator().Table("tenant_user").Tenant(7).Insert(insert).DebugString()
+	if sql != "insert into tenant_user ( name , tenant_id ) values( 'foo' , 7 )" && sql != "insert into tenant_user ( tenant_id , name ) values( 7 , 'foo' )" || len(insert) != 1 {
+		t.Errorf("unexpected sql %s", sql)
+	}
+
+	if _, _, err := NewGenerator().Table("tenant_user").SelectSql(true); err == nil {
+		t.Error("select without tenant should fail")
+	}
+	if _, _, err := NewGenerator().Table("user").Join(join).SelectSql(true); err == nil {
+		t.Error("join without tenant should fail")
+	}
+	if _, _, err := NewGenerator().Table("tenant_user").Tenant(7).Insert(map[string]any{"tenant_id": 8}).InsertSql(true); err == nil {
+		t.Error("insert into other tenant should fail")
+	}
+	if _, _, err := NewGenerator().Table("tenant_user").Tenant(7).Where(NewEqualQuery("id", 1)).Update(map[string]any{"tenant_id": 8}).UpdateSql(true); err == nil {
+		t.Error("update to other tenant should fail")
+	}
+	sql, _, _ = NewGenerator().Table("tenant_user").IgnoreTenant().SelectSql(true)
+	if sql != "select  *  from  tenant_user" {
+		t.Errorf("unexpected sql %s", sql)
+	}
+}
+
+// rawOrQuery 自定义的条件，内部用 or 连接
+type rawOrQuery string
+
+func (q rawOrQuery) Source(table string, prepare bool) (string, []any, error) {
+	return string(q), nil, nil
+}
+
+func TestGenerator_ScopeWithOr(t *testing.T) {
+	RegisterTenant("tenant_acct", "tenant_id")
+
+	queries := map[string]Query{
+		"a = 1 or b = 2": rawOrQuery("a = 1 or b = 2"),
+		"tenant_acct.a = 1 or tenant_acct.b = ⒼⓄ": NewExprQuery(RawExpr("tenant_acct.a = 1 or tenant_acct.b"), "=", 2),
+	}
+	for source, query := range queries {
+		where := "where   ( " + source + " ) and tenant_acct.tenant_id = ⒼⓄ "
+		gen := NewGenerator().Table("tenant_acct").Tenant(7).Where(query)
+		if sql, _, _ := gen.SelectSql(true); sql != "select  *  from  tenant_acct "+where {
+			t.Errorf("unexpected sql %s", sql)
+		}
+		if sql, _, _ := gen.Clone().Update(map[string]any{"name": "foo"}).UpdateSql(true); sql != "update tenant_acct set name=ⒼⓄ "+where {
+			t.Errorf("unexpected sql %s", sql)
+		}
+		if sql, _, _ := gen.DeleteSql(true); sql != "delete from tenant_acct  "+where {
+			t.Errorf("unexpected sql %s", sql)
+		}
+	}
+}
+
+func TestGenerator_Shard(t *testing.T) {
+	RegisterShard("shard_order", NewHashShard("user_id", 64))
+
+	sql, params, _ := NewGenerator().Table("shard_order").Where(NewEqualQuery("user_id", 65)).SelectSql(true)
+	if sql != "select  *  from  shard_order_01 shard_order  where    shard_order.user_id = ⒼⓄ " || fmt.Sprint(params) != "[65]" {
+		t.Errorf("unexpected sql %s %v", sql, params)
+	}
+	gen := NewGenerator().Table("shard_order").Where(NewInQuery("user_id", []any{1, 65, 2})).AddOrderBy("shard_order.id", ORDER_DESC).PageNum(2).PageSize(10)
+	sql, params, _ = gen.SelectSql(true)
+	want := "select * from ((select  *  from  shard_order_01 shard_order  where    shard_order.user_id in ( ⒼⓄ , ⒼⓄ , ⒼⓄ)  order by   shard_order.id desc limit ⒼⓄ,ⒼⓄ) union all " +
+		"(select  *  from  shard_order_02 shard_order  where    shard_order.user_id in ( ⒼⓄ , ⒼⓄ , ⒼⓄ)  order by   shard_order.id desc limit ⒼⓄ,ⒼⓄ)) t order by   id desc limit ⒼⓄ,ⒼⓄ"
+	if sql != want || fmt.Sprint(params) != "[1 65 2 0 20 1 65 2 0 20 10 10]" {
+		t.Errorf("want %s\ngot  %s %v", want, sql, params)
+	}
+	// 外层没有表名，表达式中字段的表名也要去掉
+	sql, _, _ = NewGenerator().Table("shard_order").Where(NewInQuery("user_id", []any{1, 2})).AddOrderByExpr(Col("shard_order.created_at"), ORDER_DESC).PageSize(10).SelectSql(true)
+	if !strings.Contains(sql, "order by   shard_order.created_at desc limit") || !strings.HasSuffix(sql, ") t order by   created_at desc limit ⒼⓄ,ⒼⓄ") {
+		t.Errorf("unexpected sql %s", sql)
+	}
+	sql, params, _ = NewGenerator().Table("shard_order").Where(NewEqualQuery("status", 1)).CountSql(true)
+	if !strings.HasPrefix(sql, "select sum(count) count from (select  count(*) count   from  shard_order_00 shard_order ") || strings.Count(sql, "union all") != 63 || len(params) != 64 {
+		t.Errorf("unexpected sql %s", sql)
+	}
+
+	sql, _, _ = NewGenerator().Table("shard_order").Where(NewEqualQuery("user_id", 3)).Update(map[string]any{"status": 2}).UpdateSql(true)
+	if sql != "update shard_order_03 set status=ⒼⓄ where    shard_order_03.user_id = ⒼⓄ " {
+		t.Errorf("unexpected sql %s", sql)
+	}
+	if _, _, err := NewGenerator().Table("shard_order").Where(NewEqualQuery("status", 3)).DeleteSql(true); err == nil {
+		t.Error("delete without shard column should fail")
+	}
+
+	RegisterShard("shard_soft_order", NewHashShard("user_id", 8))
+	RegisterSoftDelete("shard_soft_order", "deleted_at")
+	sql, params, _ = NewGenerator().Table("shard_soft_order").Where(NewEqualQuery("user_id", 3)).DeleteSql(true)
+	if sql != "update shard_soft_order_3 set deleted_at=now() where   ( shard_soft_order_3.user_id = ⒼⓄ ) and shard_soft_order_3.deleted_at is null " || fmt.Sprint(params) != "[3]" {
+		t.Errorf("unexpected sql %s %v", sql, params)
+	}
+
+	inserts := []map[string]any{{"user_id": 1}, {"user_id": 2}, {"user_id": 65}}
+	sqls, err := NewGenerator().Table("shard_order").Inserts(inserts).InsertShardSqls(true)
+	if err != nil || len(sqls) != 2 || sqls[0].Table != "shard_order_01" || fmt.Sprint(sqls[0].Params) != "[1 65]" || sqls[1].Table != "shard_order_02" {
+		t.Errorf("unexpected sqls %v %v", sqls, err)
+	}
+	if _, _, err := NewGenerator().Table("shard_order").Inserts(inserts).InsertSql(true); err == nil {
+		t.Error("insert across shards should fail")
+	}
+
+	from := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	date := NewDateShard("create_time", SHARD_BY_MONTH, from, time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC))
+	if tables := date.Tables("log"); fmt.Sprint(tables) != "[log_202401 log_202402 log_202403 log_202404]" {
+		t.Errorf("unexpected tables %v", tables)
+	}
+	if table, _ := date.Table("log", "2024-04-15"); table != "log_202404" {
+		t.Errorf("unexpected table %s", table)
+	}
+	if _, err := date.Table("log", "2024-05-01"); err == nil {
+		t.Error("date out of range should fail")
+	}
+	ranges := NewRangeShard("id", 100, 200)
+	if table, _ := ranges.Table("user", 150); table != "user_1" {
+		t.Errorf("unexpected table %s", table)
+	}
+	if _, err := ranges.Table("user", 250); err == nil {
+		t.Error("id out of range should fail")
+	}
+}
+
+func TestGenerator_Rewrite(t *testing.T) {
+	boolQuery := NewBoolQuery().And(NewEqualQuery("status", 1), NewInQuery("type", []any{1, 2})).Or(NewLikeQuery("name", "%foo%"), NewNullQuery("deleted_at"))
+	join := NewJoin("order", LEFT_JOIN).Condition("order", "user_id", "user", "id").Where(NewGreaterThanQuery("amount", 10))
+	gen := NewGenerator().Table("user").Join(join).Where(boolQuery)
+
+	columns := make([]string, 0)
+	gen.Walk(func(node *Node) bool {
+		if node.Kind != DSL_BOOL {
+			columns = append(columns, node.Field+" "+node.Operator())
+		}
+		return true
+	})
+	if fmt.Sprint(columns) != "[status = type in name like deleted_at is null order.user_id = amount >]" {
+		t.Errorf("unexpected columns %v", columns)
+	}
+
+	before, _, _ := gen.SelectSql(false)
+	rewritten := gen.Clone().Rewrite(func(node *Node) (*Node, error) {
+		switch {
+		case node.Kind == DSL_LIKE:
+			node.Value = "%bar%"
+		case node.Kind == DSL_NULL:
+			return nil, nil
+		case node.Kind == DSL_BOOL:
+			return &Node{Kind: DSL_BOOL, And: []*Node{node, {Kind: DSL_EQUAL, Field: "tenant_id", Value: 7}}}, nil
+		}
+		return node, nil
+	})
+	sql, params, err := rewritten.SelectSql(true)
+	want := "select  *  from  user left join order on order.user_id = user.id and order.amount > ⒼⓄ where    ( ( user.status = ⒼⓄ  and user.type in ( ⒼⓄ , ⒼⓄ)  and ( user.name like ⒼⓄ ) )  and user.tenant_id = ⒼⓄ ) "
+	if err != nil || sql != want || fmt.Sprint(params) != "[10 1 1 2 %bar% 7]" {
+		t.Errorf("want %s\ngot  %s %v %v", want, sql, params, err)
+	}
+	if after, _, _ := gen.SelectSql(false); after != before {
+		t.Errorf("rewrite changed the original generator %s", after)
+	}
+
+	sql, _, err = NewGenerator().Table("user").Where(NewEqualQuery("id", 1)).Rewrite(func(node *Node) (*Node, error) {
+		return nil, fmt.Errorf("rejected %s", node.Column())
+	}).SelectSql(true)
+	if err == nil || err.Error() != "rejected id" {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	sub := NewGenerator().Table("order").Where(NewEqualQuery("status", 1))
+	subJoin := NewSubqueryJoin(sub, "o", INNER_JOIN).Condition("o", "user_id", "user", "id")
+	subBefore, _, _ := sub.SelectSql(true)
+	NewGenerator().Table("user").Join(join, subJoin).Rewrite(func(node *Node) (*Node, error) {
+		node.Value = 2
+		return node, nil
+	})
+	if after, _, _ := sub.SelectSql(true); after != subBefore || len(join.querys) != 1 || join.querys[0].(*GreaterThanQuery).value != 10 {
+		t.Errorf("rewrite changed the join or subquery %s", after)
+	}
+
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	sql, _, _ = NewGenerator().Table("user").Where(NewSameDayQuery("create_time", at).In(time.UTC), NewTupleInQuery([]string{"a", "b"}, [][]any{{1, 2}}), NewExprQuery(Col("c"), "=", 1)).
+		Rewrite(func(node *Node) (*Node, error) {
+			if node.Raw != nil {
+				node.Table = "u"
+			}
+			return node, nil
+		}).SelectSql(true)
+	if sql != "select  *  from  user where    (u.create_time >= ⒼⓄ and u.create_time < ⒼⓄ)  or  (u.a, u.b) in ((ⒼⓄ, ⒼⓄ))  or  user.c = ⒼⓄ " {
+		t.Errorf("unexpected sql %s", sql)
+	}
+}
+
+func TestParseSql(t *testing.T) {
+	gen, kind, err := ParseSql("SELECT u.id, count(*) AS n FROM `user` u LEFT JOIN orders o ON o.user_id = u.id AND o.status = 1 WHERE (u.age >= ? AND u.name LIKE '%it''s%') OR u.id IN (1, 2, 3) GROUP BY u.id ORDER BY u.id DESC LIMIT 10 OFFSET 20", 18)
+	if err != nil || kind != STATEMENT_SELECT {
+		t.Fatalf("unexpected error %v %s", err, kind)
+	}
+	sql, params, _ := gen.SelectSql(true)
+	want := "select u.id,count(*) AS n from  user u  left join orders o on o.user_id = u.id and o.status = ⒼⓄ where    ( u.age >= ⒼⓄ  and u.name like ⒼⓄ )  or  u.id in ( ⒼⓄ , ⒼⓄ , ⒼⓄ)  group by   u.id order by   u.id desc limit ⒼⓄ,ⒼⓄ"
+	if sql != want || fmt.Sprint(params) != "[1 18 %it's% 1 2 3 20 10]" {
+		t.Errorf("want %s\ngot  %s %v", want, sql, params)
+	}
+
+	gen, _, _ = ParseSql("select * from user where age between 1 and 10 and status not in (1,2) and a != b and id = $1", 5)
+	if sql := gen.DebugString(); sql != "select  *  from  user where    ( user.age between 1 and 10  and user.status not in ( 1 , 2)  and user.a != user.b  and user.id = 5 ) " {
+		t.Errorf("unexpected sql %s", sql)
+	}
+
+	gen, kind, _ = ParseSql("update user set name = ?, age = 3 where id = ? limit 1", "x", 9)
+	if sql, params, _ := gen.UpdateSql(true); kind != STATEMENT_UPDATE || !strings.HasSuffix(sql, " where    user.id = ⒼⓄ  limit 1") || len(params) != 3 {
+		t.Errorf("unexpected sql %s", sql)
+	}
+	gen, kind, _ = ParseSql("insert into user (id, name) values (1, 'a'), (2, ?)", "b")
+	if sql, params, _ := gen.InsertSql(true); kind != STATEMENT_INSERT || strings.Count(sql, "ⒼⓄ") != 4 || len(params) != 4 {
+		t.Errorf("unexpected sql %s", sql)
+	}
+	gen, kind, _ = ParseSql("delete from user")
+	if sql, _, err := gen.DeleteSql(true); kind != STATEMENT_DELETE || sql != "delete from user " || err != nil {
+		t.Errorf("unexpected sql %s %v", sql, err)
+	}
+
+	for sql, want := range map[string]string{
+		"select * from user where id in (select id from x)":     "unsupported subquery at position 32",
+		"select * from user where not id = 1":                   "unsupported not at position 25",
+		"select * from user having x":                           "unsupported having at position 19",
+		"update user set age = age + 1 where id = 1":            "unsupported expression in set at position 22",
+		"select distinct id from user":                          "unsupported distinct at position 7",
+		"select * from user where date(create_time) = 1":        "unsupported function at position 25",
+		"select * from user where id = ? and age = ? and x = ?": "not enough params at position 52",
+		"select * from user where parent_id = null":             "= null never matches, use is null or is not null at position 37",
+		"select * from user where parent_id <> NULL":            "<> null never matches, use is null or is not null at position 38",
+		"select * from user where id = ? and age > $2":          "cannot mix ? and $n placeholders at position 42",
+		"select * from user where id = $1 and age > ?":          "cannot mix ? and $n placeholders at position 43",
+	} {
+		_, _, err := ParseSql(sql, 1, 2)
+		var parseErr *SqlParseError
+		if !errors.As(err, &parseErr) || !strings.HasPrefix(err.Error(), want) {
+			t.Errorf("%s: want %s, got %v", sql, want, err)
+		}
+	}
+	gen, _, err = ParseSql("select * from user where parent_id <=> null and deleted_at is null")
+	if sql := gen.DebugString(); err != nil || sql != "select  *  from  user where    ( user.parent_id <=> NULL  and user.deleted_at is null ) " {
+		t.Errorf("unexpected sql %s %v", sql, err)
+	}
+}
+
+func TestGenerator_PageCountSql(t *testing.T) {
+	gen := NewGenerator().Table("user").Result("id", "name").Where(NewEqualQuery("status", 1)).AddOrderBy("id", ORDER_DESC).PageNum(3).PageSize(10)
+	sql, params, _ := gen.PageCountSql(true)
+	if sql != "select  count(*) count   from  user where    user.status = ⒼⓄ " || fmt.Sprint(params) != "[1]" {
+		t.Errorf("unexpected sql %s %v", sql, params)
+	}
+	if num, size := gen.Pagination(); num != 3 || size != 10 {
+		t.Errorf("unexpected pagination %d %d", num, size)
+	}
+	if num, _ := NewGenerator().PageStart(20).PageSize(10).Pagination(); num != 3 {
+		t.Errorf("unexpected page num %d", num)
+	}
+	if offset := NewGenerator().PageStart(25).PageSize(10).Offset(); offset != 25 {
+		t.Errorf("unexpected offset %d", offset)
+	}
+	if offset := gen.Offset(); offset != 20 {
+		t.Errorf("unexpected offset %d", offset)
+	}
+	sql, _, _ = gen.Clone().GroupBy([]string{"name"}).PageCountSql(true)
+	if sql != "select count(*) count from (select id,name from  user where    user.status = ⒼⓄ  group by   name) t" {
+		t.Errorf("unexpected sql %s", sql)
+	}
+	sql, _, _ = gen.Clone().Result("distinct name").PageCountSql(true)
+	if sql != "select count(*) count from (select distinct name from  user where    user.status = ⒼⓄ ) t" {
+		t.Errorf("unexpected sql %s", sql)
+	}
+	if sql, _, _ = gen.SelectSql(true); !strings.HasSuffix(sql, "order by   id desc limit ⒼⓄ,ⒼⓄ") {
+		t.Errorf("page count sql changed the generator %s", sql)
+	}
+}
+
+func TestQuery_NullAndEmptyIn(t *testing.T) {
+	var id *int64
+	for _, c := range []struct {
+		query Query
+		sql   string
+	}{
+		{NewEqualQuery("parent_id", nil), "user.parent_id is null"},
+		{NewEqualQuery("parent_id", id), "user.parent_id is null"},
+		{NewEqualQuery("parent_id", sql.NullInt64{}), "user.parent_id is null"},
+		{NewNotEqualQuery("parent_id", nil), "user.parent_id is not null"},
+		{NewInQuery("id", []any{}), "1 = 0"},
+		{NewNotInQuery("id", nil), "1 = 1"},
+	} {
+		source, params, err := c.query.Source("user", true)
+		if err != nil || source != c.sql || len(params) != 0 {
+			t.Errorf("want %s, got %s %v %v", c.sql, source, params, err)
+		}
+	}
+	if source, params, _ := NewEqualQuery("parent_id", sql.NullInt64{Int64: 1, Valid: true}).Source("user", true); source != "user.parent_id = ⒼⓄ" || len(params) != 1 {
+		t.Errorf("unexpected sql %s %v", source, params)
+	}
+
+	gen := NewGenerator().Table("user").Where(NewNullSafeEqualQuery("parent_id", nil), NewNullSafeNotEqualQuery("status", 1))
+	sqlStr, params, _ := gen.SelectSql(true)
+	if sqlStr != "select  *  from  user where    user.parent_id <=> ⒼⓄ  or  not (user.status <=> ⒼⓄ) " || fmt.Sprint(params) != "[<nil> 1]" {
+		t.Errorf("unexpected sql %s %v", sqlStr, params)
+	}
+	sqlStr, _, _ = gen.Dialect(DIALECT_POSTGRES).SelectSql(true)
+	if !strings.Contains(sqlStr, "parent_id is not distinct from ⒼⓄ  or  user.status is distinct from ⒼⓄ") {
+		t.Errorf("unexpected sql %s", sqlStr)
+	}
+
+	parsed, _, err := ParseSql("select * from user where parent_id <=> ? and status is distinct from 1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sqlStr, _, _ = parsed.SelectSql(true); !strings.Contains(sqlStr, "user.parent_id <=> ⒼⓄ  and not (user.status <=> ⒼⓄ)") {
+		t.Errorf("unexpected sql %s", sqlStr)
+	}
+}
+
+func TestQuery_Pattern(t *testing.T) {
+	postgres := Dialect{Name: DIALECT_POSTGRES}
+	for _, c := range []struct {
+		query   DialectQuery
+		dialect Dialect
+		sql     string
+		param   string
+	}{
+		{NewContainsQuery("name", "50%_off!"), Dialect{}, "user.name like ⒼⓄ escape '!'", "%50!%!_off!!%"},
+		{NewStartsWithQuery("name", "la"), Dialect{}, "user.name like ⒼⓄ escape '!'", "la%"},
+		{NewEndsWithQuery("name", "er").IgnoreCase(), Dialect{}, "lower(user.name) like lower(ⒼⓄ) escape '!'", "%er"},
+		{NewEndsWithQuery("name", "er").IgnoreCase(), postgres, "user.name ilike ⒼⓄ escape '!'", "%er"},
+		{NewNotContainsQuery("name", "50%_off!"), Dialect{}, "user.name not like ⒼⓄ escape '!'", "%50!%!_off!!%"},
+		{NewNotContainsQuery("name", "a_").IgnoreCase(), Dialect{}, "lower(user.name) not like lower(ⒼⓄ) escape '!'", "%a!_%"},
+		{NewNotContainsQuery("name", "a_").IgnoreCase(), postgres, "user.name not ilike ⒼⓄ escape '!'", "%a!_%"},
+		{NewILikeQuery("name", "%Lazy%"), Dialect{}, "lower(user.name) like lower(ⒼⓄ)", "%Lazy%"},
+		{NewRegexpQuery("name", "^la"), Dialect{}, "user.name regexp ⒼⓄ", "^la"},
+		{NewRegexpQuery("name", "^la").IgnoreCase(), Dialect{}, "regexp_like(user.name, ⒼⓄ, 'i')", "^la"},
+		{NewRegexpQuery("name", "^la"), postgres, "user.name ~ ⒼⓄ", "^la"},
+		{NewRegexpQuery("name", "^la").IgnoreCase(), postgres, "user.name ~* ⒼⓄ", "^la"},
+	} {
+		source, params, err := c.query.DialectSource(c.dialect, "user", true)
+		if err != nil || source != c.sql || len(params) != 1 || params[0] != c.param {
+			t.Errorf("want %s %s, got %s %v %v", c.sql, c.param, source, params, err)
+		}
+	}
+	if source, _, _ := NewContainsQuery("name", "it's").Source("user", false); source != "user.name like '%it''s%' escape '!'" {
+		t.Errorf("unexpected sql %s", source)
+	}
+
+	query, err := ParseQuery([]byte(`{"contains": {"field": "name", "value": "a_b", "ignore_case": true}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := json.Marshal(query)
+	if string(data) != `{"contains":{"field":"name","ignore_case":true,"value":"a_b"}}` {
+		t.Errorf("unexpected json %s", data)
+	}
+	notContains, err := ParseQuery([]byte(`{"not_contains": {"field": "name", "value": "a%"}}`))
+	if source, params, _ := notContains.Source("user", true); err != nil || source != "user.name not like ⒼⓄ escape '!'" || params[0] != "%a!%%" {
+		t.Errorf("unexpected sql %s %v %v", source, params, err)
+	}
+	if data, _ = json.Marshal(notContains); string(data) != `{"not_contains":{"field":"name","value":"a%"}}` {
+		t.Errorf("unexpected json %s", data)
+	}
+	if _, err := ParseQuery([]byte(`{"regexp": {"field": "name", "value": 1}}`)); err == nil {
+		t.Error("want error for non string regexp")
+	}
+	if node := NewNode(query); node.Kind != DSL_CONTAINS || !node.IgnoreCase || node.Query().(*ContainsQuery).value != "a_b" {
+		t.Errorf("unexpected node %+v", node)
+	}
+}
+
+func TestQuery_TimeRange(t *testing.T) {
+	shanghai := time.FixedZone("Asia/Shanghai", 8*3600)
+	// 2024-03-06 23:30 UTC 是上海的 2024-03-07 07:30，周四
+	at := time.Date(2024, 3, 6, 23, 30, 0, 0, time.UTC)
+	day := func(d int) time.Time { return time.Date(2024, 3, d, 0, 0, 0, 0, shanghai) }
+	for _, c := range []struct {
+		query      *TimeRangeQuery
+		start, end time.Time
+	}{
+		{NewSameDayQuery("create_time", at).In(shanghai), day(7), day(8)},
+		{NewSameWeekQuery("create_time", at).In(shanghai), day(4), day(11)},
+		{NewSameMonthQuery("create_time", at).In(shanghai), day(1), time.Date(2024, 4, 1, 0, 0, 0, 0, shanghai)},
+		{NewLastNDaysQueryAt("create_time", 7, at).In(shanghai), day(1), day(8)},
+		{NewSameDayQuery("create_time", at).In(time.UTC), time.Date(2024, 3, 6, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 7, 0, 0, 0, 0, time.UTC)},
+	} {
+		start, end, err := c.query.Bounds()
+		if err != nil || !start.Equal(c.start) || !end.Equal(c.end) {
+			t.Errorf("want [%v, %v), got [%v, %v) %v", c.start, c.end, start, end, err)
+		}
+	}
+
+	SetTimeLocation(shanghai)
+	defer SetTimeLocation(nil)
+	source, params, _ := NewSameDayQuery("create_time", at).Source("order", true)
+	if source != "(order.create_time >= ⒼⓄ and order.create_time < ⒼⓄ)" || len(params) != 2 || !params[0].(time.Time).Equal(day(7)) {
+		t.Errorf("unexpected sql %s %v", source, params)
+	}
+	if source, _, _ = NewSameDayQuery("create_time", at).Source("order", false); source != "(order.create_time >= '2024-03-07 00:00:00' and order.create_time < '2024-03-08 00:00:00')" {
+		t.Errorf("unexpected sql %s", source)
+	}
+	if _, _, err := NewTimeRangeQuery("create_time", day(8), day(7)).Source("order", true); err == nil {
+		t.Error("want error for empty range")
+	}
+
+	gen := NewGenerator().Table("order").Result("count(*) count").GroupByDate("create_time", TIME_UNIT_DAY, "day").Where(NewLastNDaysQueryAt("create_time", 7, at))
+	sql, _, _ := gen.SelectSql(true)
+	// 按 SetTimeLocation 设置的时区分组
+	if sql != "select date_format(convert_tz(order.create_time, '+00:00', 'Asia/Shanghai'), '%Y-%m-%d') day,count(*) count from  order where    (order.create_time >= ⒼⓄ and order.create_time < ⒼⓄ)  group by   date_format(convert_tz(order.create_time, '+00:00', 'Asia/Shanghai'), '%Y-%m-%d')" {
+		t.Errorf("unexpected sql %s", sql)
+	}
+	sql, _, _ = gen.Clone().Dialect(DIALECT_POSTGRES).GroupByDate("create_time", TIME_UNIT_MONTH, "month").SelectSql(true)
+	if !strings.Contains(sql, "group by   to_char((order.create_time at time zone 'Asia/Shanghai'), 'YYYY-MM-DD'), to_char((order.create_time at time zone 'Asia/Shanghai'), 'YYYY-MM')") {
+		t.Errorf("unexpected sql %s", sql)
+	}
+	sql, _, _ = NewGenerator().Table("order").GroupByDateIn("create_time", TIME_UNIT_DAY, "day", time.FixedZone("", -5*3600)).SelectSql(true)
+	if sql != "select date_format(convert_tz(order.create_time, '+00:00', '-05:00'), '%Y-%m-%d') day from  order group by   date_format(convert_tz(order.create_time, '+00:00', '-05:00'), '%Y-%m-%d')" {
+		t.Errorf("unexpected sql %s", sql)
+	}
+	SetTimeLocation(nil)
+	if sql, _, _ = NewGenerator().Table("order").GroupByDate("create_time", TIME_UNIT_DAY, "day").SelectSql(true); !strings.HasPrefix(sql, "select date_format(order.create_time, '%Y-%m-%d') day") {
+		t.Errorf("group by date without location should use the server time zone %s", sql)
+	}
+	SetTimeLocation(shanghai)
+	if sql, _, _ = gen.PageCountSql(true); !strings.HasPrefix(sql, "select count(*) count from (select date_format") {
+		t.Errorf("unexpected sql %s", sql)
+	}
+	if _, _, err := NewGenerator().Table("order").GroupByDate("create_time", "year", "y").SelectSql(true); err == nil {
+		t.Error("want error for unknown unit")
+	}
+
+	// json 往返后边界不变
+	local, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, query := range []*TimeRangeQuery{
+		NewTimeRangeQueryWithTable("o", "create_time", day(1), day(8)),
+		NewLastNDaysQueryAt("create_time", 7, at).In(local),
+		NewSameWeekQuery("create_time", at).Table("o"),
+	} {
+		data, err := json.Marshal(NewBoolQuery().And(query))
+		if err != nil {
+			t.Fatal(err)
+		}
+		parsed, err := ParseQuery(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, wantParams, _ := query.Source("order", true)
+		got, gotParams, _ := parsed.Source("order", true)
+		if got != "( "+want+" )" || len(gotParams) != 2 || !gotParams[0].(time.Time).Equal(wantParams[0].(time.Time)) || !gotParams[1].(time.Time).Equal(wantParams[1].(time.Time)) {
+			t.Errorf("want %s %v, got %s %v (%s)", want, wantParams, got, gotParams, data)
+		}
+	}
+	for _, invalid := range []string{
+		`{"time_range":{"field":"create_time","from":"2024-03-01"}}`,
+		`{"time_range":{"field":"create_time","unit":"year","at":"2024-03-01T00:00:00Z"}}`,
+		`{"time_range":{"field":"create_time","unit":"day","at":"2024-03-01T00:00:00Z","location":"Mars/Base"}}`,
+	} {
+		if _, err := ParseQuery([]byte(invalid)); err == nil {
+			t.Errorf("expected error for %s", invalid)
+		}
+	}
+}
+
+func TestQuery_TupleIn(t *testing.T) {
+	query := NewTupleInQuery([]string{"order_id", "sku"}, [][]any{{1, "a"}, {2, "b"}})
+	source, params, err := query.Source("item", true)
+	if err != nil || source != "(item.order_id, item.sku) in ((ⒼⓄ, ⒼⓄ), (ⒼⓄ, ⒼⓄ))" || fmt.Sprint(params) != "[1 a 2 b]" {
+		t.Errorf("unexpected sql %s %v %v", source, params, err)
+	}
+	source, _, _ = CloneQuery(query).(*TupleInQuery).Expand().Source("item", false)
+	if source != "((item.order_id = 1 and item.sku = 'a') or (item.order_id = 2 and item.sku = 'b'))" {
+		t.Errorf("unexpected sql %s", source)
+	}
+	if source, _, _ = query.Source("item", true); !strings.HasPrefix(source, "(item.order_id, item.sku) in") {
+		t.Errorf("clone changed the query %s", source)
+	}
+	if source, params, _ := NewTupleInQuery([]string{"order_id", "sku"}, nil).Source("item", true); source != "1 = 0" || len(params) != 0 {
+		t.Errorf("unexpected sql %s %v", source, params)
+	}
+	if _, _, err := NewTupleInQuery([]string{"order_id", "sku"}, [][]any{{1}}).Source("item", true); err == nil {
+		t.Error("want error for row size mismatch")
+	}
+
+	updates := []map[string]any{{"order_id": 1, "sku": "a", "num": 3}, {"order_id": 2, "sku": "b", "num": 5}}
+	gen := NewGenerator().Table("item").Primary("order_id", "sku").Where(NewTupleInQuery([]string{"order_id", "sku"}, [][]any{{1, "a"}, {2, "b"}})).Updates(updates)
+	sql, params, err := gen.UpdateSql(true)
+	if err != nil || !strings.Contains(sql, "num = CASE WHEN order_id = ⒼⓄ and sku = ⒼⓄ THEN ⒼⓄ WHEN order_id = ⒼⓄ and sku = ⒼⓄ THEN ⒼⓄ END") ||
+		!strings.HasSuffix(sql, "where    (item.order_id, item.sku) in ((ⒼⓄ, ⒼⓄ), (ⒼⓄ, ⒼⓄ)) ") {
+		t.Errorf("unexpected sql %s %v", sql, err)
+	}
+	if len(params) != 3*6+4 {
+		t.Errorf("unexpected params %v", params)
+	}
+	if _, _, err := gen.Clone().Updates([]map[string]any{{"order_id": 1, "num": 3}}).UpdateSql(true); err == nil {
+		t.Error("want error for missing primary")
+	}
+
+	// json 往返后条件不变
+	for _, query := range []*TupleInQuery{
+		NewTupleInQueryWithTable("i", []string{"order_id", "sku"}, [][]any{{1, "a"}, {2, nil}}),
+		NewTupleInQuery([]string{"order_id", "sku"}, [][]any{{1, "a"}}).Expand(),
+	} {
+		data, err := json.Marshal(NewBoolQuery().And(query))
+		if err != nil {
+			t.Fatal(err)
+		}
+		parsed, err := ParseQuery(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, wantParams, _ := query.Source("item", true)
+		got, gotParams, _ := parsed.Source("item", true)
+		if got != "( "+want+" )" || fmt.Sprint(gotParams) != fmt.Sprint(wantParams) {
+			t.Errorf("want %s %v, got %s %v (%s)", want, wantParams, got, gotParams, data)
+		}
+	}
+	parser := NewQueryParser().Allow(AllowList{"order": "order_id", "sku": "sku"}).MaxValues(4)
+	parsed, err := parser.Parse([]byte(`{"tuple_in":{"fields":["order","sku"],"values":[[1,"a"]]}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if source, _, _ := parsed.Source("item", true); source != "(item.order_id, item.sku) in ((ⒼⓄ, ⒼⓄ))" {
+		t.Errorf("unexpected sql %s", source)
+	}
+	for _, invalid := range []string{
+		`{"tuple_in":{"fields":["order","password"],"values":[[1,"a"]]}}`,
+		`{"tuple_in":{"fields":["order","sku"],"values":[[1]]}}`,
+		`{"tuple_in":{"fields":["order","sku"],"values":[[1,"a"],[2,"b"],[3,"c"]]}}`,
+		`{"tuple_in":{"fields":[],"values":[]}}`,
+	} {
+		if _, err := parser.Parse([]byte(invalid)); err == nil {
+			t.Errorf("expected error for %s", invalid)
+		}
+	}
+}
+
+func TestQuery_ArrayIn(t *testing.T) {
+	gen := NewGenerator().Table("user").Dialect(DIALECT_POSTGRES).ArrayIn(true).Where(NewInQuery("id", []any{1, 2, 3}), NewNotInQuery("name", []any{"a\"b", nil}))
+	sql, params, err := gen.SelectSql(true)
+	if err != nil || sql != "select  *  from  user where    user.id = ANY(ⒼⓄ)  or  user.name <> ALL(ⒼⓄ) " || len(params) != 2 {
+		t.Fatalf("unexpected sql %s %v %v", sql, params, err)
+	}
+	for i, want := range []string{"{1,2,3}", `{"a\"b",NULL}`} {
+		value, err := params[i].(driver.Valuer).Value()
+		if err != nil || value != want {
+			t.Errorf("want %s, got %v %v", want, value, err)
+		}
+	}
+	if sql, _, _ = gen.Clone().Dialect(DIALECT_MYSQL).SelectSql(true); !strings.Contains(sql, "user.id in ( ⒼⓄ , ⒼⓄ , ⒼⓄ)") {
+		t.Errorf("mysql should not use array %s", sql)
+	}
+	query := NewInQuery("id", []any{1, 2}).Array(false)
+	if sql, _, _ = NewGenerator().Table("user").Dialect(DIALECT_POSTGRES).ArrayIn(true).Where(query).SelectSql(true); !strings.Contains(sql, "user.id in ( ⒼⓄ , ⒼⓄ)") {
+		t.Errorf("query should override generator %s", sql)
+	}
+	query = NewInQuery("id", []any{1, 2}).Array(true)
+	if sql, _, _ = NewGenerator().Table("user").Dialect(DIALECT_POSTGRES).Where(query).Rewrite(func(n *Node) (*Node, error) { return n, nil }).SelectSql(false); !strings.Contains(sql, "user.id = ANY('{1,2}')") {
+		t.Errorf("unexpected sql %s", sql)
+	}
+	if _, err := (PgArray{[]byte("x")}).Value(); err == nil {
+		t.Error("want error for []byte element")
+	}
+}
+
+func TestGenerator_Expr(t *testing.T) {
+	total := Col("price").Mul(Col("num")).Sub(Val(5))
+	gen := NewGenerator().Table("item").
+		Result("sku").
+		ResultExpr(Sum(total).As("amount"), Coalesce(Col("remark"), "").As("remark"), Cast(Col("price"), "decimal(10,2)").As("price")).
+		Where(total.Gt(100), Col("deleted_at").Eq(nil)).
+		GroupBy([]string{"sku"}).
+		GroupByExpr(Fn("date", Col("create_time"))).
+		AddOrderByExpr(Sum(total), ORDER_DESC).
+		PageSize(10)
+	sql, params, err := gen.SelectSql(true)
+	want := "select sku,sum(((item.price * item.num) - ⒼⓄ)) as amount,coalesce(item.remark, ⒼⓄ) as remark,cast(item.price as decimal(10,2)) as price from  item " +
+		"where    ((item.price * item.num) - ⒼⓄ) > ⒼⓄ  or  item.deleted_at is null  group by   sku, date(item.create_time) order by   sum(((item.price * item.num) - ⒼⓄ)) desc limit ⒼⓄ,ⒼⓄ"
+	if err != nil || sql != want {
+		t.Errorf("unexpected sql %s %v", sql, err)
+	}
+	if fmt.Sprint(params) != "[5  5 100 5 0 10]" {
+		t.Errorf("unexpected params %v", params)
+	}
+	if sql, _, _ = gen.PageCountSql(true); !strings.HasPrefix(sql, "select count(*) count from (select sku,sum(") {
+		t.Errorf("unexpected sql %s", sql)
+	}
+
+	sql, _, _ = NewGenerator().Table("item").Where(RawExpr("json_extract(attrs, ?)", "$.color").Eq("red")).SelectSql(false)
+	if sql != "select  *  from  item where    json_extract(attrs, '$.color') = 'red' " {
+		t.Errorf("unexpected sql %s", sql)
+	}
+	sql, params, err = NewGenerator().Table("item").ResultExpr(RawExpr("concat(name, '?', ?, 'it''s ?')", "!").As("title")).SelectSql(true)
+	if err != nil || sql != "select concat(name, '?', ⒼⓄ, 'it''s ?') as title from  item" || fmt.Sprint(params) != "[!]" {
+		t.Errorf("unexpected sql %s %v %v", sql, params, err)
+	}
+	if sql, _, _ = NewGenerator().Table("item").ResultExpr(RawExpr("concat(name, '?')")).SelectSql(false); sql != "select concat(name, '?') from  item" {
+		t.Errorf("unexpected sql %s", sql)
+	}
+	sql, params, _ = NewGenerator().Table("item").Update(map[string]any{"num": 0}).Where(NewEqualQuery("sku", "a")).AddOrderByExpr(Fn("abs", Col("num").Sub(3)), ORDER_ASC).Limit(1).UpdateSql(true)
+	if sql != "update item set num=ⒼⓄ where    item.sku = ⒼⓄ  order by   abs((item.num - ⒼⓄ)) asc limit 1" || fmt.Sprint(params) != "[0 a 3]" {
+		t.Errorf("unexpected sql %s %v", sql, params)
+	}
+	for _, expr := range []*Expr{Col("a b"), Fn("sleep(1);", 1), Cast(Col("a"), "int; drop"), Col("a").As("x y"), RawExpr("f(?)"), Coalesce()} {
+		if _, _, err := NewGenerator().Table("item").ResultExpr(expr).SelectSql(true); err == nil {
+			t.Errorf("want error for %v", expr)
+		}
+	}
+}
+
+func TestGenerator_Sort(t *testing.T) {
+	gen := NewGenerator().Table("user").
+		Sort(Desc("score").NullsLast(), FieldOrder("status", 2, 1, 3), Asc(Col("name").Add(1)).NullsFirst(), Asc("total"))
+	sql, params, err := gen.SelectSql(true)
+	want := "select  *  from  user order by   score is null asc, score desc, CASE status WHEN ⒼⓄ THEN 0 WHEN ⒼⓄ THEN 1 WHEN ⒼⓄ THEN 2 ELSE 3 END asc, " +
+		"(user.name + ⒼⓄ) is null desc, (user.name + ⒼⓄ) asc, total asc"
+	if err != nil || sql != want || fmt.Sprint(params) != "[2 1 3 1 1]" {
+		t.Errorf("unexpected sql %s %v %v", sql, params, err)
+	}
+	sql, _, _ = gen.Clone().Dialect(DIALECT_POSTGRES).SelectSql(false)
+	if !strings.HasPrefix(sql, "select  *  from  user order by   score desc nulls last, CASE status WHEN 2 THEN 0 WHEN 1 THEN 1 WHEN 3 THEN 2 ELSE 3 END asc, (user.name + 1) asc nulls first") {
+		t.Errorf("unexpected sql %s", sql)
+	}
+	if _, _, err := NewGenerator().Table("user").Sort(Asc("name; drop")).SelectSql(true); err == nil {
+		t.Error("want error for invalid column")
+	}
+
+	sql, _, _ = NewGenerator().Table("user").Result("sex", "count(*) count").AddGroupBy("", "sex").SelectSql(true)
+	if sql != "select sex,count(*) count from  user group by   sex" {
+		t.Errorf("unexpected sql %s", sql)
+	}
+
+	parsed, _, err := ParseSql("select * from user order by score desc nulls last, id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sql, _, _ = parsed.Dialect(DIALECT_POSTGRES).SelectSql(true); !strings.HasSuffix(sql, "order by   score desc nulls last, id asc") {
+		t.Errorf("unexpected sql %s", sql)
+	}
+}
+
+func TestGenerator_Optional(t *testing.T) {
+	var age *int
+	zero := 0
+	name, ids := "", []any{}
+	gen := NewGenerator().Table("user").
+		Where(NewBoolQuery().
+			And(NewOptionalEqualQuery("name", name), NewOptionalInQuery("id", ids), NewOptionalEqualQuery("age", age)).
+			And(NewOptionalEqualQuery("status", sql.NullInt64{}), NewOptionalEqualQuery("level", &zero)).
+			And(NewBoolQuery().Or(NewOptionalContainsQuery("remark", ""))).
+			And(NewOptionalBetweenQuery("create_time", time.Time{}, "2024-01-01"))).
+		WhereIf(name != "", NewEqualQuery("nick", name))
+	sql, params, err := gen.SelectSql(true)
+	if err != nil || sql != "select  *  from  user where    ( user.level = ⒼⓄ  and user.create_time <= ⒼⓄ ) " || len(params) != 2 || params[1] != "2024-01-01" {
+		t.Errorf("unexpected sql %s %v %v", sql, params, err)
+	}
+
+	sql, _, _ = NewGenerator().Table("user").Where(NewBoolQuery().And(NewOptionalEqualQuery("name", ""))).WhereIf(false, NewEqualQuery("id", 1)).SelectSql(true)
+	if sql != "select  *  from  user" {
+		t.Errorf("unexpected sql %s", sql)
+	}
+	join := NewJoin("order", LEFT_JOIN).Condition("user", "id", "order", "user_id").Where(NewBoolQuery().And(NewOptionalEqualQuery("status", 0)))
+	if sql, _, _ = NewGenerator().Table("user").Join(join).SelectSql(true); sql != "select  *  from  user left join order on user.id = order.user_id" {
+		t.Errorf("unexpected sql %s", sql)
+	}
+	if _, _, err := NewGenerator().Table("user").Where(NewOptionalEqualQuery("id", 0)).DeleteSql(true); err == nil {
+		t.Error("want error for delete without condition")
+	}
+}
+
+func TestGenerator_Validate(t *testing.T) {
+	for _, c := range []struct {
+		sql    string
+		params []any
+	}{
+		{"select * from user where id = " + PLACE_HOLDER_GO, nil},
+		{"select * from user where (id = 1", nil},
+		{"select * from user where id = 1)", nil},
+		{"select * from user where  order by id", nil},
+		{"select * from user where ( and id = 1)", nil},
+		{"select * from user where id = 1 or", nil},
+		{"select * from user where () and id = 1", nil},
+		{"select * from user where id in ()", nil},
+	} {
+		if err := ValidateSql(c.sql, c.params); err == nil {
+			t.Errorf("want error for %s", c.sql)
+		}
+	}
+	if err := ValidateSql("select count(*) from user where name = 'a (b or' and id between "+PLACE_HOLDER_GO+" and "+PLACE_HOLDER_GO, []any{1, 2}); err != nil {
+		t.Error(err)
+	}
+
+	RegisterSchema("validate_user", "id", "name", "score")
+	RegisterSchema("validate_order", "id", "user_id")
+	if fmt.Sprint(SchemaColumns("validate_user")) != "[id name score]" {
+		t.Errorf("unexpected columns %v", SchemaColumns("validate_user"))
+	}
+	join := NewJoin("validate_order", LEFT_JOIN).Alias("o").Condition("u", "id", "o", "user_id")
+	gen := NewGenerator().Table("validate_user").TableAlias("u").Join(join).
+		Result("u.name", "count(*) count").ResultExpr(Sum(Col("score")).As("total")).
+		Where(NewEqualQuery("name", "a"), NewFieldEqualQuery("id", "score")).
+		AddGroupBy("", "u.name").AddOrderBy("total", ORDER_DESC)
+	if err := gen.Validate(); err != nil {
+		t.Error(err)
+	}
+	for _, gen := range []*Generator{
+		gen.Clone().Where(NewEqualQuery("nick", "a")),
+		gen.Clone().Result("o.amount"),
+		gen.Clone().AddOrderBy("age", ORDER_ASC),
+		NewGenerator().Table("validate_user").Join(NewJoin("validate_order", INNER_JOIN).Where(NewEqualQuery("status", 1))),
+		NewGenerator().Table("validate_user").Where(NewEqualQuery("id", 1)).Update(map[string]any{"age": 1}),
+		NewGenerator().Table("validate_user").Where(NewTupleInQuery([]string{"id", "uid"}, [][]any{{1, 2}})),
+	} {
+		if err := gen.Validate(); err == nil || !strings.Contains(err.Error(), "unknown column") {
+			t.Errorf("want unknown column error, got %v", err)
+		}
+	}
+	if err := NewGenerator().Table("unregistered").Where(NewEqualQuery("anything", 1)).Validate(); err != nil {
+		t.Error(err)
+	}
+
+	SetValidate(true)
+	defer SetValidate(false)
+	if _, _, err := NewGenerator().Table("validate_user").Result("age").SelectSql(true); err == nil {
+		t.Error("want error for unknown column")
+	}
+	if _, _, err := NewGenerator().Table("validate_user").Where(NewEqualQuery("id", 1)).Update(map[string]any{"name": "a"}).UpdateSql(false); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestGenerator_Compile(t *testing.T) {
+	gen := NewGenerator().Table("user").Result("id", "name").
+		Where(NewBoolQuery().And(NewEqualQuery("status", 1), NewEqualQuery("name", Slot("name")), NewGreaterThanQuery("age", Slot("age")))).
+		AddOrderBy("id", ORDER_DESC).PageSize(10)
+	stmt, err := gen.Compile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sql, _, _ := gen.SelectSql(true)
+	if stmt.Sql() != sql || fmt.Sprint(stmt.Slots()) != "[name age]" {
+		t.Errorf("unexpected statement %s %v", stmt.Sql(), stmt.Slots())
+	}
+	params, err := stmt.Bind(map[string]any{"name": "tom", "age": 18})
+	if err != nil || fmt.Sprint(params) != "[1 tom 18 0 10]" {
+		t.Errorf("unexpected params %v %v", params, err)
+	}
+	if params, _ = stmt.Bind(map[string]any{"name": "jim", "age": 20}); fmt.Sprint(params) != "[1 jim 20 0 10]" {
+		t.Errorf("unexpected params %v", params)
+	}
+	if _, err := stmt.Bind(map[string]any{"name": "tom"}); err == nil {
+		t.Error("want error for unbound slot")
+	}
+	if _, err := stmt.Bind(map[string]any{"name": "tom", "age": 18, "sex": 1}); err == nil {
+		t.Error("want error for unknown slot")
+	}
+	if _, err := Slot("age").Value(); err == nil {
+		t.Error("want error for unbound slot value")
+	}
+
+	stmt, err = NewGenerator().Table("user").Where(NewEqualQuery("id", Slot("id"))).StatementKind(STATEMENT_DELETE).Compile()
+	if err != nil || stmt.Sql() != "delete from user  where    user.id = ⒼⓄ " || fmt.Sprint(stmt.Slots()) != "[id]" {
+		t.Errorf("unexpected statement %v %v", stmt, err)
+	}
+
+	stmt, err = NewGenerator().Table("user").Dialect(DIALECT_POSTGRES).ArrayIn(true).Where(NewInQuery("id", []any{Slot("ids")})).Compile()
+	if err != nil || stmt.Sql() != "select  *  from  user where    user.id = ANY(ⒼⓄ) " {
+		t.Fatalf("unexpected statement %v %v", stmt, err)
+	}
+	params, err = stmt.Bind(map[string]any{"ids": []int{1, 2, 3}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value, _ := params[0].(driver.Valuer).Value(); value != "{1,2,3}" {
+		t.Errorf("unexpected array %v", value)
+	}
+
+	stmt, err = NewStatement(NewGenerator().Table("user").Where(NewEqualQuery("id", Slot("id"))).Update(map[string]any{"name": Slot("name")}).UpdateSql(true))
+	if err != nil || fmt.Sprint(stmt.Slots()) != "[name id]" {
+		t.Errorf("unexpected statement %v %v", stmt, err)
+	}
+	RegisterShard("compile_order", NewHashShard("user_id", 2))
+	if _, err := NewGenerator().Table("compile_order").Where(NewEqualQuery("user_id", Slot("user_id"))).Compile(); err == nil {
+		t.Error("want error for shard table")
+	}
+}
+
+func benchmarkGenerator() *Generator {
+	return NewGenerator().Table("user").Result("id", "name", "age").
+		Where(NewBoolQuery().And(NewEqualQuery("status", 1), NewEqualQuery("name", Slot("name")), NewInQuery("type", []any{1, 2, 3}))).
+		AddOrderBy("id", ORDER_DESC).PageNum(1).PageSize(10)
+}
+
+func BenchmarkSelectSql(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := benchmarkGenerator().SelectSql(true); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkStatementBind(b *testing.B) {
+	stmt, err := benchmarkGenerator().Compile()
+	if err != nil {
+		b.Fatal(err)
+	}
+	values := map[string]any{"name": "tom"}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := stmt.Bind(values); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
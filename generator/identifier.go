@@ -0,0 +1,86 @@
+package generator
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	ORDER_ASC  = "asc"  // 升序
+	ORDER_DESC = "desc" // 降序
+)
+
+var identifierRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_$]*$`)
+
+// ValidateIdentifier 校验表名、字段名，支持 column、table.column、schema.table.column 三种形式
+func ValidateIdentifier(name string) error {
+	parts := strings.Split(name, ".")
+	if len(parts) > 3 {
+		return fmt.Errorf("invalid identifier %q", name)
+	}
+	for _, part := range parts {
+		if !identifierRegexp.MatchString(part) {
+			return fmt.Errorf("invalid identifier %q", name)
+		}
+	}
+	return nil
+}
+
+// QuoteIdentifier 校验并给标识符加引号，mysql `table`.`column`，postgres "table"."column"
+func QuoteIdentifier(name string, dialect string) (string, error) {
+	if err := ValidateIdentifier(name); err != nil {
+		return "", err
+	}
+	return quoteIdentifier(name, dialect), nil
+}
+
+func quoteIdentifier(name string, dialect string) string {
+	quote := "`"
+	if dialect == DIALECT_POSTGRES {
+		quote = `"`
+	}
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		parts[i] = quote + part + quote
+	}
+	return strings.Join(parts, ".")
+}
+
+func isQualified(name string) bool {
+	return strings.Contains(name, ".")
+}
+
+// ValidateOrderByType 排序方式只允许 asc、desc
+func ValidateOrderByType(orderByType string) (string, error) {
+	t := strings.ToLower(strings.TrimSpace(orderByType))
+	if t != ORDER_ASC && t != ORDER_DESC {
+		return "", fmt.Errorf("invalid order by type %q, must be asc or desc", orderByType)
+	}
+	return t, nil
+}
+
+// AllowList 字段白名单，key 为对外暴露的字段名，value 为真实的列名
+// 用于把前端传入的排序、过滤字段映射成真实列名，不在白名单中的字段一律拒绝
+type AllowList map[string]string
+
+// NewAllowList 创建白名单，columns 中的列名对外暴露的名字与列名相同
+func NewAllowList(columns ...string) AllowList {
+	allow := make(AllowList, len(columns))
+	for _, column := range columns {
+		allow[column] = column
+	}
+	return allow
+}
+
+// Column 根据对外字段名获取真实列名
+func (a AllowList) Column(key string) (string, error) {
+	column, ok := a[key]
+	if !ok {
+		return "", fmt.Errorf("field %q is not allowed", key)
+	}
+	if err := ValidateIdentifier(column); err != nil {
+		return "", err
+	}
+	return column, nil
+}
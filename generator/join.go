@@ -1,30 +1,175 @@
 package generator
 
-import "fmt"
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+)
 
 type Join struct {
 	tableName string
-	condition string
-	joinType  string //inner  left  right
-	querys    []Query
+	alias     string
+	subquery  *Generator //派生表
+	joinType  string     //inner  left  right full cross
+	on        []Query    //on 条件，用 and 连接
+	using     []string
+	querys    []Query //附加条件，用 and 连接
+	orQuerys  []Query //附加条件，用 or 连接后作为一个整体
 }
 
+func NewJoin(from, joinType string) *Join {
+	return &Join{
+		tableName: from,
+		joinType:  joinType,
+	}
+}
+
+// NewSubqueryJoin 关联派生表，例如 left join (select ...) t on ...，alias 不能为空
+func NewSubqueryJoin(subquery *Generator, alias, joinType string) *Join {
+	return &Join{
+		subquery: subquery,
+		alias:    alias,
+		joinType: joinType,
+	}
+}
+
+// Alias 表的别名，自关联时使用
+func (s *Join) Alias(alias string) *Join {
+	s.alias = alias
+	return s
+}
+
+// Where 附加条件，和 on 条件用 and 连接
 func (s *Join) Where(query ...Query) *Join {
 	if s.querys == nil {
 		s.querys = make([]Query, 0)
 	}
-	s.querys = append(s.querys, query...)
+	s.querys = append(s.querys, presentQueries(query)...)
+	return s
+}
+
+// OrWhere 附加条件，多个条件之间用 or 连接，整体再和 on 条件用 and 连接
+func (s *Join) OrWhere(query ...Query) *Join {
+	s.orQuerys = append(s.orQuerys, presentQueries(query)...)
 	return s
 }
 
-// Condition Join 条件
+// Condition Join 条件，多次调用时用 and 连接
 func (s *Join) Condition(firstTable string, firstField string, secondTable string, secondField string) *Join {
-	s.condition = fmt.Sprintf("%v.%v=%v.%v", firstTable, firstField, secondTable, secondField)
+	return s.On(NewFieldEqualQuery(qualify(firstTable, firstField), qualify(secondTable, secondField)))
+}
+
+// On 任意的 on 条件，多个条件用 and 连接，未指定表名的字段使用 join 的表名或别名
+func (s *Join) On(query ...Query) *Join {
+	s.on = append(s.on, presentQueries(query)...)
 	return s
 }
-func NewJoin(from, joinType string) *Join {
-	return &Join{
-		tableName: from,
-		joinType:  joinType,
+
+// Using using (column, ...) 形式的关联条件
+func (s *Join) Using(columns ...string) *Join {
+	s.using = append(s.using, columns...)
+	return s
+}
+
+func qualify(table, field string) string {
+	if table == "" {
+		return field
+	}
+	return table + "." + field
+}
+
+// table 条件中未指定表名时使用的表名
+func (s *Join) table() string {
+	if s.alias != "" {
+		return s.alias
+	}
+	return s.tableName
+}
+
+// source 渲染整个 join 子句，scopes 为软删除等自动附加的条件，on 条件加上括号后和 scopes 用 and 连接
+func (s *Join) source(parent *Generator, scopes []Query, prepare bool) (string, []any, error) {
+	dialect := parent.dialect
+	if !joinTypes[s.joinType] {
+		return "", nil, fmt.Errorf("invalid join type %q", s.joinType)
+	}
+	if s.joinType == FULL_JOIN && !dialect.isPostgres() {
+		return "", nil, errors.New("full outer join is not supported by mysql")
+	}
+	params := make([]any, 0)
+	var sql bytes.Buffer
+	sql.WriteString(" " + s.joinType + " ")
+	if s.subquery != nil {
+		if s.alias == "" {
+			return "", nil, errors.New("subquery join must have an alias")
+		}
+		sub := s.subquery.Clone()
+		sub.inheritScope(parent)
+		source, param, err := sub.SelectSql(prepare)
+		if err != nil {
+			return "", nil, err
+		}
+		sql.WriteString("(" + source + ")")
+		params = append(params, param...)
+	} else {
+		table, err := dialect.identifier(s.tableName)
+		if err != nil {
+			return "", nil, err
+		}
+		sql.WriteString(table)
+	}
+	if s.alias != "" {
+		alias, err := dialect.identifier(s.alias)
+		if err != nil {
+			return "", nil, err
+		}
+		sql.WriteString(" " + alias)
+	}
+
+	if len(s.using) > 0 {
+		columns := make([]string, 0, len(s.using))
+		for _, column := range s.using {
+			if isQualified(column) {
+				return "", nil, fmt.Errorf("using column %q cannot be qualified", column)
+			}
+			c, err := dialect.identifier(column)
+			if err != nil {
+				return "", nil, err
+			}
+			columns = append(columns, c)
+		}
+		sql.WriteString(" using (" + strings.Join(columns, ",") + ")")
+	}
+
+	queries := append(cloneSlice(s.on), s.querys...)
+	if len(s.orQuerys) > 0 {
+		queries = append(queries, NewBoolQuery().Or(s.orQuerys...))
+	}
+	sources := make([]string, 0, len(queries)+len(scopes))
+	for i, query := range append(queries, scopes...) {
+		source, param, err := querySource(query, dialect, s.table(), prepare)
+		if err != nil {
+			return "", nil, err
+		}
+		if source == "" {
+			continue
+		}
+		if i == len(queries) && len(sources) > 0 {
+			// 自己的条件加上括号，避免其中的 or 绕过 scopes
+			sources = []string{"(" + strings.Join(sources, " and ") + ")"}
+		}
+		sources = append(sources, source)
+		params = append(params, param...)
+	}
+	if len(sources) == 0 {
+		return sql.String(), params, nil
+	}
+	if s.joinType == CROSS_JOIN {
+		return "", nil, errors.New("cross join cannot have on conditions")
+	}
+	if len(s.using) > 0 {
+		return "", nil, errors.New("join cannot have both using and on conditions, soft delete tables need Condition instead of Using")
 	}
+	sql.WriteString(" on " + strings.Join(sources, " and "))
+	return sql.String(), params, nil
 }
@@ -0,0 +1,152 @@
+package generator
+
+import (
+	"reflect"
+)
+
+// isEmptyValue nil、nil指针、零值、空字符串、空 slice 和 map、Valid 为 false 的 sql.NullXxx 为空
+// 指向零值的指针不为空，可以用指针区分没有传值和传了零值
+func isEmptyValue(value any) bool {
+	if isNullValue(value) {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map, reflect.String:
+		return v.Len() == 0
+	}
+	return v.IsZero()
+}
+
+// presentQueries 去掉 nil 条件，NewOptional 开头的条件值为空时为 nil
+func presentQueries(queries []Query) []Query {
+	for _, query := range queries {
+		if query == nil {
+			result := make([]Query, 0, len(queries))
+			for _, q := range queries {
+				if q != nil {
+					result = append(result, q)
+				}
+			}
+			return result
+		}
+	}
+	return queries
+}
+
+// WhereIf cond 为 true 时添加条件，相当于 mybatis 的 <if test="">
+func (s *Generator) WhereIf(cond bool, query ...Query) *Generator {
+	if !cond {
+		return s
+	}
+	return s.Where(query...)
+}
+
+// AndIf cond 为 true 时添加用 and 连接的条件
+func (q *BoolQuery) AndIf(cond bool, queries ...Query) *BoolQuery {
+	if !cond {
+		return q
+	}
+	return q.And(queries...)
+}
+
+// OrIf cond 为 true 时添加用 or 连接的条件
+func (q *BoolQuery) OrIf(cond bool, queries ...Query) *BoolQuery {
+	if !cond {
+		return q
+	}
+	return q.Or(queries...)
+}
+
+// NewOptionalEqualQuery value 为空时返回 nil，Where、And、Or 会忽略 nil 条件，其他 NewOptional 开头的条件相同
+func NewOptionalEqualQuery(field string, value any) Query {
+	if isEmptyValue(value) {
+		return nil
+	}
+	return NewEqualQuery(field, value)
+}
+func NewOptionalNotEqualQuery(field string, value any) Query {
+	if isEmptyValue(value) {
+		return nil
+	}
+	return NewNotEqualQuery(field, value)
+}
+func NewOptionalGreaterThanQuery(field string, value any) Query {
+	if isEmptyValue(value) {
+		return nil
+	}
+	return NewGreaterThanQuery(field, value)
+}
+func NewOptionalGreaterThanOrEqualQuery(field string, value any) Query {
+	if isEmptyValue(value) {
+		return nil
+	}
+	return NewGreaterThanOrEqualQuery(field, value)
+}
+func NewOptionalLessThanQuery(field string, value any) Query {
+	if isEmptyValue(value) {
+		return nil
+	}
+	return NewLessThanQuery(field, value)
+}
+func NewOptionalLessThanOrEqualQuery(field string, value any) Query {
+	if isEmptyValue(value) {
+		return nil
+	}
+	return NewLessThanOrEqualQuery(field, value)
+}
+func NewOptionalLikeQuery(field string, value any) Query {
+	if isEmptyValue(value) {
+		return nil
+	}
+	return NewLikeQuery(field, value)
+}
+func NewOptionalNotLikeQuery(field string, value any) Query {
+	if isEmptyValue(value) {
+		return nil
+	}
+	return NewNotLikeQuery(field, value)
+}
+func NewOptionalInQuery(field string, value []any) Query {
+	if len(value) == 0 {
+		return nil
+	}
+	return NewInQuery(field, value)
+}
+func NewOptionalNotInQuery(field string, value []any) Query {
+	if len(value) == 0 {
+		return nil
+	}
+	return NewNotInQuery(field, value)
+}
+func NewOptionalContainsQuery(field, value string) Query {
+	if value == "" {
+		return nil
+	}
+	return NewContainsQuery(field, value)
+}
+func NewOptionalStartsWithQuery(field, value string) Query {
+	if value == "" {
+		return nil
+	}
+	return NewStartsWithQuery(field, value)
+}
+func NewOptionalEndsWithQuery(field, value string) Query {
+	if value == "" {
+		return nil
+	}
+	return NewEndsWithQuery(field, value)
+}
+
+// NewOptionalBetweenQuery 两个值都为空时返回 nil，只有一个值时为 >= 或 <=
+func NewOptionalBetweenQuery(field string, firstValue, secondValue any) Query {
+	switch first, second := isEmptyValue(firstValue), isEmptyValue(secondValue); {
+	case first && second:
+		return nil
+	case second:
+		return NewGreaterThanOrEqualQuery(field, firstValue)
+	case first:
+		return NewLessThanOrEqualQuery(field, secondValue)
+	}
+	return NewBetweenQuery(field, firstValue, secondValue)
+}
@@ -0,0 +1,1061 @@
+package generator
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	STATEMENT_SELECT = "select"
+	STATEMENT_INSERT = "insert"
+	STATEMENT_UPDATE = "update"
+	STATEMENT_DELETE = "delete"
+)
+
+// SqlParseError 解析sql失败的位置和原因
+type SqlParseError struct {
+	Pos     int    // 出错的位置，sql中的字节偏移
+	Near    string // 出错位置开始的一段sql
+	Message string
+}
+
+func (e *SqlParseError) Error() string {
+	return fmt.Sprintf("%s at position %d near %q", e.Message, e.Pos, e.Near)
+}
+
+// ParseSql 把手写的sql解析成 Generator，返回语句类型 STATEMENT_*，params 为sql中占位符 ? 或 $n 对应的参数
+//
+// 支持 Generator 能表达的 mysql/postgres 子集：
+//
+//	select 字段 from 表 [别名] [join ... on/using] [where] [group by 字段] [order by 字段 asc|desc] [limit]
+//	insert into 表 (字段) values (...), (...)
+//	update 表 set 字段 = 值, ... [where] [order by] [limit]
+//	delete from 表 [where] [order by] [limit]
+//
+// where 和 on 支持 and、or、括号，以及 = != <> < <= > >= <=> like ilike regexp in between is null is distinct from，右边可以是值、占位符或字段
+// 子查询、not、having、union、函数条件等不支持的写法返回 *SqlParseError，包含出错的位置
+// 比较运算符右边不能直接写 null，需要使用 is null；? 和 $n 占位符不能混用
+// 双引号按标识符处理；使用了 $n 占位符时方言设置为postgres；没有where的 update、delete 会调用 AllowFullTable
+func ParseSql(sql string, params ...any) (*Generator, string, error) {
+	tokens, err := lexSql(sql)
+	if err != nil {
+		return nil, "", err
+	}
+	p := &sqlParser{sql: sql, tokens: tokens, params: params, gen: NewGenerator()}
+	kind, err := p.parse()
+	if err != nil {
+		return nil, "", err
+	}
+	return p.gen.StatementKind(kind), kind, nil
+}
+
+const (
+	tokenEOF = iota
+	tokenIdent
+	tokenQuoted
+	tokenString
+	tokenNumber
+	tokenParam
+	tokenSymbol
+)
+
+type sqlToken struct {
+	kind int
+	text string // 引号内的标识符、反转义后的字符串、其余为原文
+	pos  int
+	end  int
+}
+
+// lexSql 把sql拆分成token，跳过空白和注释
+func lexSql(sql string) ([]sqlToken, error) {
+	tokens := make([]sqlToken, 0)
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		start := i
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+			continue
+		case strings.HasPrefix(sql[i:], "--"):
+			for i < len(sql) && sql[i] != '\n' {
+				i++
+			}
+			continue
+		case strings.HasPrefix(sql[i:], "/*"):
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				return nil, parseError(sql, i, "unterminated comment")
+			}
+			i += end + 4
+			continue
+		case isIdentStart(c):
+			for i < len(sql) && isIdentPart(sql[i]) {
+				i++
+			}
+			tokens = append(tokens, sqlToken{kind: tokenIdent, text: sql[start:i], pos: start, end: i})
+		case c == '`' || c == '"':
+			end := strings.IndexByte(sql[i+1:], c)
+			if end < 0 {
+				return nil, parseError(sql, i, "unterminated identifier")
+			}
+			i += end + 2
+			tokens = append(tokens, sqlToken{kind: tokenQuoted, text: sql[start+1 : i-1], pos: start, end: i})
+		case c == '\'':
+			var buf strings.Builder
+			i++
+			for {
+				if i >= len(sql) {
+					return nil, parseError(sql, start, "unterminated string")
+				}
+				if sql[i] == '\\' && i+1 < len(sql) {
+					buf.WriteByte(sql[i+1])
+					i += 2
+					continue
+				}
+				if sql[i] == '\'' {
+					if i+1 < len(sql) && sql[i+1] == '\'' {
+						buf.WriteByte('\'')
+						i += 2
+						continue
+					}
+					i++
+					break
+				}
+				buf.WriteByte(sql[i])
+				i++
+			}
+			tokens = append(tokens, sqlToken{kind: tokenString, text: buf.String(), pos: start, end: i})
+		case c >= '0' && c <= '9':
+			for i < len(sql) && (sql[i] >= '0' && sql[i] <= '9' || sql[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, sqlToken{kind: tokenNumber, text: sql[start:i], pos: start, end: i})
+		case c == '?':
+			i++
+			tokens = append(tokens, sqlToken{kind: tokenParam, text: "?", pos: start, end: i})
+		case c == '$':
+			i++
+			for i < len(sql) && sql[i] >= '0' && sql[i] <= '9' {
+				i++
+			}
+			if i == start+1 {
+				return nil, parseError(sql, start, "invalid placeholder")
+			}
+			tokens = append(tokens, sqlToken{kind: tokenParam, text: sql[start:i], pos: start, end: i})
+		default:
+			for _, symbol := range []string{"<=>", "<=", ">=", "<>", "!=", "(", ")", ",", ".", "=", "<", ">", "*", ";", "-", "+", "/", "%"} {
+				if strings.HasPrefix(sql[i:], symbol) {
+					i += len(symbol)
+					break
+				}
+			}
+			if i == start {
+				return nil, parseError(sql, i, "unexpected character")
+			}
+			tokens = append(tokens, sqlToken{kind: tokenSymbol, text: sql[start:i], pos: start, end: i})
+		}
+	}
+	return append(tokens, sqlToken{kind: tokenEOF, pos: len(sql), end: len(sql)}), nil
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || c >= '0' && c <= '9' || c == '$'
+}
+
+func parseError(sql string, pos int, message string) *SqlParseError {
+	end := pos + 20
+	if end > len(sql) {
+		end = len(sql)
+	}
+	return &SqlParseError{Pos: pos, Near: sql[pos:end], Message: message}
+}
+
+// 不能作为表别名的关键字
+var sqlKeywords = map[string]bool{
+	"select": true, "from": true, "where": true, "join": true, "inner": true, "left": true, "right": true,
+	"full": true, "cross": true, "outer": true, "on": true, "using": true, "group": true, "order": true,
+	"by": true, "having": true, "limit": true, "offset": true, "union": true, "set": true, "values": true,
+	"and": true, "or": true, "not": true, "natural": true, "for": true, "window": true, "returning": true,
+}
+
+type sqlParser struct {
+	sql      string
+	tokens   []sqlToken
+	pos      int
+	params   []any
+	next     int  // 下一个 ? 使用的参数
+	numbered bool // 使用了 $n 占位符，不能和 ? 混用
+	gen      *Generator
+}
+
+func (p *sqlParser) peek() sqlToken {
+	return p.tokens[p.pos]
+}
+func (p *sqlParser) advance() sqlToken {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+// isKeyword 当前token是否为指定的关键字
+func (p *sqlParser) isKeyword(keywords ...string) bool {
+	t := p.peek()
+	if t.kind != tokenIdent {
+		return false
+	}
+	for _, keyword := range keywords {
+		if strings.EqualFold(t.text, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// acceptKeyword 当前token为指定的关键字时跳过
+func (p *sqlParser) acceptKeyword(keyword string) bool {
+	if p.isKeyword(keyword) {
+		p.pos++
+		return true
+	}
+	return false
+}
+func (p *sqlParser) expectKeyword(keyword string) error {
+	if !p.acceptKeyword(keyword) {
+		return p.errorf("expected %s", keyword)
+	}
+	return nil
+}
+func (p *sqlParser) isSymbol(symbol string) bool {
+	t := p.peek()
+	return t.kind == tokenSymbol && t.text == symbol
+}
+func (p *sqlParser) acceptSymbol(symbol string) bool {
+	if p.isSymbol(symbol) {
+		p.pos++
+		return true
+	}
+	return false
+}
+func (p *sqlParser) expectSymbol(symbol string) error {
+	if !p.acceptSymbol(symbol) {
+		return p.errorf("expected %q", symbol)
+	}
+	return nil
+}
+
+// errorf 在当前token的位置报错
+func (p *sqlParser) errorf(format string, args ...any) error {
+	return parseError(p.sql, p.peek().pos, fmt.Sprintf(format, args...))
+}
+
+// unsupported 不支持的写法
+func (p *sqlParser) unsupported(what string) error {
+	return p.errorf("unsupported %s", what)
+}
+
+func (p *sqlParser) parse() (string, error) {
+	var kind string
+	var err error
+	switch {
+	case p.acceptKeyword("select"):
+		kind, err = STATEMENT_SELECT, p.parseSelect()
+	case p.acceptKeyword("insert"):
+		kind, err = STATEMENT_INSERT, p.parseInsert()
+	case p.acceptKeyword("update"):
+		kind, err = STATEMENT_UPDATE, p.parseUpdate()
+	case p.acceptKeyword("delete"):
+		kind, err = STATEMENT_DELETE, p.parseDelete()
+	default:
+		return "", p.unsupported("statement")
+	}
+	if err != nil {
+		return "", err
+	}
+	p.acceptSymbol(";")
+	if p.peek().kind != tokenEOF {
+		if p.isKeyword("union", "having", "for", "returning", "window", "on") {
+			return "", p.unsupported(strings.ToLower(p.peek().text))
+		}
+		return "", p.errorf("unexpected token")
+	}
+	if !p.numbered && p.next != len(p.params) {
+		return "", fmt.Errorf("sql has %d placeholders but got %d params", p.next, len(p.params))
+	}
+	if p.numbered {
+		p.gen.Dialect(DIALECT_POSTGRES)
+	}
+	return kind, nil
+}
+
+func (p *sqlParser) parseSelect() error {
+	if p.isKeyword("distinct", "all") {
+		return p.unsupported(strings.ToLower(p.peek().text))
+	}
+	columns, err := p.parseColumns()
+	if err != nil {
+		return err
+	}
+	if len(columns) != 1 || columns[0] != "*" {
+		p.gen.Result(columns...)
+	}
+	if err := p.expectKeyword("from"); err != nil {
+		return err
+	}
+	table, alias, err := p.parseTable()
+	if err != nil {
+		return err
+	}
+	p.gen.Table(table)
+	if alias != "" {
+		p.gen.TableAlias(alias)
+	}
+	if p.isSymbol(",") {
+		return p.unsupported("comma join")
+	}
+	for {
+		join, err := p.parseJoin()
+		if err != nil {
+			return err
+		}
+		if join == nil {
+			break
+		}
+		p.gen.Join(join)
+	}
+	if err := p.parseWhere(); err != nil {
+		return err
+	}
+	if p.acceptKeyword("group") {
+		if err := p.expectKeyword("by"); err != nil {
+			return err
+		}
+		groupBy := make([]string, 0)
+		for {
+			name, err := p.parseColumn()
+			if err != nil {
+				return err
+			}
+			groupBy = append(groupBy, name)
+			if !p.acceptSymbol(",") {
+				break
+			}
+		}
+		p.gen.GroupBy(groupBy)
+	}
+	if p.isKeyword("having") {
+		return p.unsupported("having")
+	}
+	if err := p.parseOrderBy(); err != nil {
+		return err
+	}
+	return p.parseLimit(false)
+}
+
+// parseColumns 查询的字段，每一项按原文保留，支持函数和别名
+func (p *sqlParser) parseColumns() ([]string, error) {
+	columns := make([]string, 0)
+	start, depth := p.peek(), 0
+	for {
+		t := p.peek()
+		switch {
+		case t.kind == tokenEOF:
+			return nil, p.errorf("expected from")
+		case t.kind == tokenIdent && strings.EqualFold(t.text, "select"):
+			return nil, p.unsupported("subquery")
+		case t.kind == tokenParam:
+			return nil, p.unsupported("placeholder in select list")
+		case depth == 0 && (t.kind == tokenIdent && strings.EqualFold(t.text, "from") || p.isSymbol(",")):
+			if t.pos == start.pos {
+				return nil, p.errorf("expected column")
+			}
+			columns = append(columns, strings.TrimSpace(p.sql[start.pos:p.tokens[p.pos-1].end]))
+			if p.isSymbol(",") {
+				p.advance()
+				start = p.peek()
+				continue
+			}
+			return columns, nil
+		case p.isSymbol("("):
+			depth++
+		case p.isSymbol(")"):
+			depth--
+		}
+		p.advance()
+	}
+}
+
+// parseName 标识符，支持 table.column 和 schema.table.column，去掉引号
+func (p *sqlParser) parseName() (string, error) {
+	parts := make([]string, 0, 3)
+	for {
+		t := p.peek()
+		if t.kind != tokenIdent && t.kind != tokenQuoted {
+			return "", p.errorf("expected identifier")
+		}
+		if t.kind == tokenIdent && sqlKeywords[strings.ToLower(t.text)] {
+			return "", p.errorf("expected identifier")
+		}
+		p.advance()
+		parts = append(parts, t.text)
+		if !p.isSymbol(".") {
+			break
+		}
+		p.advance()
+	}
+	name := strings.Join(parts, ".")
+	if err := ValidateIdentifier(name); err != nil {
+		return "", parseError(p.sql, p.tokens[p.pos-1].pos, err.Error())
+	}
+	return name, nil
+}
+
+// parseColumn 条件、排序、分组中的字段，不支持函数
+func (p *sqlParser) parseColumn() (string, error) {
+	name, err := p.parseName()
+	if err != nil {
+		return "", err
+	}
+	if p.isSymbol("(") {
+		return "", parseError(p.sql, p.tokens[p.pos-1].pos, "unsupported function")
+	}
+	return name, nil
+}
+
+// parseTable 表名和可选的别名
+func (p *sqlParser) parseTable() (string, string, error) {
+	if p.isSymbol("(") {
+		return "", "", p.unsupported("subquery")
+	}
+	table, err := p.parseName()
+	if err != nil {
+		return "", "", err
+	}
+	if p.acceptKeyword("as") {
+		alias, err := p.parseName()
+		return table, alias, err
+	}
+	t := p.peek()
+	if t.kind == tokenQuoted || t.kind == tokenIdent && !sqlKeywords[strings.ToLower(t.text)] {
+		alias, err := p.parseName()
+		return table, alias, err
+	}
+	return table, "", nil
+}
+
+// parseJoin 没有join时返回nil
+func (p *sqlParser) parseJoin() (*Join, error) {
+	var joinType string
+	switch {
+	case p.acceptKeyword("join"):
+		return p.parseJoinTable(INNER_JOIN)
+	case p.acceptKeyword("inner"):
+		joinType = INNER_JOIN
+	case p.acceptKeyword("left"):
+		joinType = LEFT_JOIN
+	case p.acceptKeyword("right"):
+		joinType = RIGHT_JOIN
+	case p.acceptKeyword("full"):
+		joinType = FULL_JOIN
+	case p.acceptKeyword("cross"):
+		joinType = CROSS_JOIN
+	case p.isKeyword("natural"):
+		return nil, p.unsupported("natural join")
+	default:
+		return nil, nil
+	}
+	p.acceptKeyword("outer")
+	if err := p.expectKeyword("join"); err != nil {
+		return nil, err
+	}
+	return p.parseJoinTable(joinType)
+}
+
+func (p *sqlParser) parseJoinTable(joinType string) (*Join, error) {
+	table, alias, err := p.parseTable()
+	if err != nil {
+		return nil, err
+	}
+	join := NewJoin(table, joinType)
+	if alias != "" {
+		join.Alias(alias)
+	}
+	switch {
+	case p.acceptKeyword("on"):
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		join.On(expr.queries("and")...)
+	case p.acceptKeyword("using"):
+		if err := p.expectSymbol("("); err != nil {
+			return nil, err
+		}
+		for {
+			name, err := p.parseName()
+			if err != nil {
+				return nil, err
+			}
+			join.Using(name)
+			if !p.acceptSymbol(",") {
+				break
+			}
+		}
+		if err := p.expectSymbol(")"); err != nil {
+			return nil, err
+		}
+	}
+	return join, nil
+}
+
+func (p *sqlParser) parseWhere() error {
+	if !p.acceptKeyword("where") {
+		return nil
+	}
+	expr, err := p.parseOr()
+	if err != nil {
+		return err
+	}
+	//Generator 的多个条件之间是 or 的关系
+	p.gen.Where(expr.queries("or")...)
+	return nil
+}
+
+func (p *sqlParser) parseOrderBy() error {
+	if !p.acceptKeyword("order") {
+		return nil
+	}
+	if err := p.expectKeyword("by"); err != nil {
+		return err
+	}
+	for {
+		name, err := p.parseColumn()
+		if err != nil {
+			return err
+		}
+		orderByType := ORDER_ASC
+		if p.acceptKeyword("desc") {
+			orderByType = ORDER_DESC
+		} else {
+			p.acceptKeyword("asc")
+		}
+		sort := newSort(name, orderByType)
+		if p.acceptKeyword("nulls") {
+			switch {
+			case p.acceptKeyword("first"):
+				sort.NullsFirst()
+			case p.acceptKeyword("last"):
+				sort.NullsLast()
+			default:
+				return p.unsupported("nulls")
+			}
+		}
+		p.gen.Sort(sort)
+		if !p.acceptSymbol(",") {
+			return nil
+		}
+	}
+}
+
+// parseLimit 支持 limit n、limit m,n、limit n offset m，更新、删除不支持 offset
+func (p *sqlParser) parseLimit(write bool) error {
+	if !p.acceptKeyword("limit") {
+		if p.isKeyword("offset") {
+			return p.unsupported("offset without limit")
+		}
+		return nil
+	}
+	first, err := p.parseInt()
+	if err != nil {
+		return err
+	}
+	if write {
+		if p.isSymbol(",") || p.isKeyword("offset") {
+			return p.unsupported("offset in update or delete")
+		}
+		p.gen.Limit(first)
+		return nil
+	}
+	switch {
+	case p.acceptSymbol(","):
+		size, err := p.parseInt()
+		if err != nil {
+			return err
+		}
+		p.gen.PageStart(first).PageSize(size)
+	case p.acceptKeyword("offset"):
+		start, err := p.parseInt()
+		if err != nil {
+			return err
+		}
+		p.gen.PageStart(start).PageSize(first)
+	default:
+		p.gen.Limit(first)
+	}
+	return nil
+}
+
+func (p *sqlParser) parseInt() (int, error) {
+	t := p.peek()
+	value, err := p.parseValue()
+	if err != nil {
+		return 0, err
+	}
+	n, err := intValue(value)
+	if err != nil || n < 0 {
+		return 0, parseError(p.sql, t.pos, "expected non-negative integer")
+	}
+	return int(n), nil
+}
+
+func (p *sqlParser) parseInsert() error {
+	if err := p.expectKeyword("into"); err != nil {
+		return err
+	}
+	table, err := p.parseName()
+	if err != nil {
+		return err
+	}
+	p.gen.Table(table)
+	if err := p.expectSymbol("("); err != nil {
+		return err
+	}
+	fields := make([]string, 0)
+	for {
+		name, err := p.parseName()
+		if err != nil {
+			return err
+		}
+		fields = append(fields, name)
+		if !p.acceptSymbol(",") {
+			break
+		}
+	}
+	if err := p.expectSymbol(")"); err != nil {
+		return err
+	}
+	if p.isKeyword("select") {
+		return p.unsupported("insert select")
+	}
+	if err := p.expectKeyword("values"); err != nil {
+		return err
+	}
+	rows := make([]map[string]any, 0)
+	for {
+		if err := p.expectSymbol("("); err != nil {
+			return err
+		}
+		row := make(map[string]any, len(fields))
+		for i, field := range fields {
+			if i > 0 {
+				if err := p.expectSymbol(","); err != nil {
+					return err
+				}
+			}
+			value, err := p.parseValue()
+			if err != nil {
+				return err
+			}
+			row[field] = value
+		}
+		if err := p.expectSymbol(")"); err != nil {
+			return err
+		}
+		rows = append(rows, row)
+		if !p.acceptSymbol(",") {
+			break
+		}
+	}
+	if p.isKeyword("on") {
+		return p.unsupported("on duplicate key / on conflict")
+	}
+	if len(rows) == 1 {
+		p.gen.Insert(rows[0])
+	} else {
+		p.gen.Inserts(rows)
+	}
+	return nil
+}
+
+func (p *sqlParser) parseUpdate() error {
+	table, err := p.parseName()
+	if err != nil {
+		return err
+	}
+	p.gen.Table(table)
+	if !p.isKeyword("set") {
+		return p.unsupported("alias or join in update")
+	}
+	p.advance()
+	update := make(map[string]any)
+	for {
+		name, err := p.parseName()
+		if err != nil {
+			return err
+		}
+		if err := p.expectSymbol("="); err != nil {
+			return err
+		}
+		if t := p.peek(); t.kind == tokenQuoted || t.kind == tokenIdent && !p.isKeyword("null", "true", "false") {
+			return p.unsupported("expression in set")
+		}
+		value, err := p.parseValue()
+		if err != nil {
+			return err
+		}
+		if !p.isSymbol(",") && !p.isKeyword("where", "order", "limit") && !p.isSymbol(";") && p.peek().kind != tokenEOF {
+			return p.unsupported("expression in set")
+		}
+		update[name] = value
+		if !p.acceptSymbol(",") {
+			break
+		}
+	}
+	p.gen.Update(update)
+	return p.parseWriteTail()
+}
+
+func (p *sqlParser) parseDelete() error {
+	if err := p.expectKeyword("from"); err != nil {
+		return err
+	}
+	table, err := p.parseName()
+	if err != nil {
+		return err
+	}
+	p.gen.Table(table)
+	if p.isKeyword("using") || p.isSymbol(",") {
+		return p.unsupported("multi-table delete")
+	}
+	return p.parseWriteTail()
+}
+
+// parseWriteTail 更新、删除的 where、order by、limit，没有where时允许全表
+func (p *sqlParser) parseWriteTail() error {
+	if !p.isKeyword("where") {
+		p.gen.AllowFullTable()
+	}
+	if err := p.parseWhere(); err != nil {
+		return err
+	}
+	if err := p.parseOrderBy(); err != nil {
+		return err
+	}
+	return p.parseLimit(true)
+}
+
+// sqlExpr where 条件的表达式树，op 为 and、or，叶子节点为 query
+type sqlExpr struct {
+	op       string
+	children []*sqlExpr
+	query    Query
+}
+
+func (e *sqlExpr) toQuery() Query {
+	if e.query != nil {
+		return e.query
+	}
+	query := NewBoolQuery()
+	for _, child := range e.children {
+		if e.op == "and" {
+			query.And(child.toQuery())
+		} else {
+			query.Or(child.toQuery())
+		}
+	}
+	return query
+}
+
+// queries 最外层是 op 时拆成多个条件，否则为一个条件
+func (e *sqlExpr) queries(op string) []Query {
+	if e.op != op {
+		return []Query{e.toQuery()}
+	}
+	queries := make([]Query, 0, len(e.children))
+	for _, child := range e.children {
+		queries = append(queries, child.toQuery())
+	}
+	return queries
+}
+
+func (p *sqlParser) parseOr() (*sqlExpr, error) {
+	return p.parseBinary("or", p.parseAnd)
+}
+func (p *sqlParser) parseAnd() (*sqlExpr, error) {
+	return p.parseBinary("and", p.parsePrimary)
+}
+
+func (p *sqlParser) parseBinary(op string, operand func() (*sqlExpr, error)) (*sqlExpr, error) {
+	first, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	if !p.isKeyword(op) {
+		return first, nil
+	}
+	expr := &sqlExpr{op: op, children: []*sqlExpr{first}}
+	for p.acceptKeyword(op) {
+		child, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		expr.children = append(expr.children, child)
+	}
+	return expr, nil
+}
+
+func (p *sqlParser) parsePrimary() (*sqlExpr, error) {
+	if p.isKeyword("not", "exists") {
+		return nil, p.unsupported(strings.ToLower(p.peek().text))
+	}
+	if p.acceptSymbol("(") {
+		if p.isKeyword("select") {
+			return nil, p.unsupported("subquery")
+		}
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return expr, p.expectSymbol(")")
+	}
+	query, err := p.parsePredicate()
+	if err != nil {
+		return nil, err
+	}
+	return &sqlExpr{query: query}, nil
+}
+
+// parsePredicate 字段 操作符 值，左边必须是字段
+func (p *sqlParser) parsePredicate() (Query, error) {
+	if t := p.peek(); t.kind != tokenIdent && t.kind != tokenQuoted {
+		return nil, p.unsupported("value on left side of condition")
+	}
+	field, err := p.parseColumn()
+	if err != nil {
+		return nil, err
+	}
+	if p.acceptKeyword("is") {
+		not := p.acceptKeyword("not")
+		if p.acceptKeyword("distinct") {
+			if err := p.expectKeyword("from"); err != nil {
+				return nil, err
+			}
+			value, err := p.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			if not {
+				return NewNullSafeEqualQuery(field, value), nil
+			}
+			return NewNullSafeNotEqualQuery(field, value), nil
+		}
+		if err := p.expectKeyword("null"); err != nil {
+			return nil, err
+		}
+		if not {
+			return NewNotNullQuery(field), nil
+		}
+		return NewNullQuery(field), nil
+	}
+	not := p.acceptKeyword("not")
+	switch {
+	case p.acceptKeyword("in"):
+		if err := p.expectSymbol("("); err != nil {
+			return nil, err
+		}
+		if p.isKeyword("select") {
+			return nil, p.unsupported("subquery")
+		}
+		values := make([]any, 0)
+		for {
+			value, err := p.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+			if !p.acceptSymbol(",") {
+				break
+			}
+		}
+		if err := p.expectSymbol(")"); err != nil {
+			return nil, err
+		}
+		if not {
+			return NewNotInQuery(field, values), nil
+		}
+		return NewInQuery(field, values), nil
+	case p.acceptKeyword("between"):
+		from, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectKeyword("and"); err != nil {
+			return nil, err
+		}
+		to, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		if not {
+			return NewNotBetweenQuery(field, from, to), nil
+		}
+		return NewBetweenQuery(field, from, to), nil
+	case p.acceptKeyword("like"):
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		if p.isKeyword("escape") {
+			return nil, p.unsupported("escape")
+		}
+		if not {
+			return NewNotLikeQuery(field, value), nil
+		}
+		return NewLikeQuery(field, value), nil
+	case !not && p.acceptKeyword("ilike"):
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		return NewILikeQuery(field, value), nil
+	case !not && p.acceptKeyword("regexp"):
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		text, ok := value.(string)
+		if !ok {
+			return nil, p.unsupported("regexp value")
+		}
+		return NewRegexpQuery(field, text), nil
+	case not:
+		return nil, p.unsupported("not")
+	}
+
+	t := p.peek()
+	if t.kind != tokenSymbol {
+		return nil, p.unsupported("operator")
+	}
+	operator := t.text
+	switch operator {
+	case "=", "!=", "<>", "<", "<=", ">", ">=", "<=>":
+		p.advance()
+	default:
+		return nil, p.unsupported("operator")
+	}
+	if next := p.peek(); next.kind == tokenQuoted || next.kind == tokenIdent && !p.isKeyword("null", "true", "false") {
+		if operator == "<=>" {
+			return nil, p.unsupported("<=> between fields")
+		}
+		other, err := p.parseColumn()
+		if err != nil {
+			return nil, err
+		}
+		return fieldQuery(operator, field, other), nil
+	}
+	if operator != "<=>" && p.isKeyword("null") {
+		// = null 永远不成立，不能改写成 is null
+		return nil, p.errorf("%s null never matches, use is null or is not null", operator)
+	}
+	value, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind == tokenSymbol && strings.Contains("+-*/%", t.text) {
+		return nil, p.unsupported("expression")
+	}
+	switch operator {
+	case "=":
+		return NewEqualQuery(field, value), nil
+	case "<=>":
+		return NewNullSafeEqualQuery(field, value), nil
+	case "!=", "<>":
+		return NewNotEqualQuery(field, value), nil
+	case "<":
+		return NewLessThanQuery(field, value), nil
+	case "<=":
+		return NewLessThanOrEqualQuery(field, value), nil
+	case ">":
+		return NewGreaterThanQuery(field, value), nil
+	}
+	return NewGreaterThanOrEqualQuery(field, value), nil
+}
+
+// fieldQuery 字段和字段比较
+func fieldQuery(operator, first, second string) Query {
+	switch operator {
+	case "=":
+		return NewFieldEqualQuery(first, second)
+	case "!=", "<>":
+		return NewFieldNotEqualQuery(first, second)
+	case "<":
+		return NewFieldLessThanQuery(first, second)
+	case "<=":
+		return NewFieldLessThanOrEqualQuery(first, second)
+	case ">":
+		return NewFieldGreaterThanQuery(first, second)
+	}
+	return NewFieldGreaterThanOrEqualQuery(first, second)
+}
+
+// parseValue 字符串、数字、true、false、null 或占位符，整数解析为int64
+func (p *sqlParser) parseValue() (any, error) {
+	t := p.peek()
+	switch {
+	case t.kind == tokenString:
+		p.advance()
+		return t.text, nil
+	case t.kind == tokenNumber || p.isSymbol("-"):
+		start := p.advance()
+		if start.kind == tokenSymbol {
+			if p.peek().kind != tokenNumber {
+				return nil, p.unsupported("expression")
+			}
+			p.advance()
+		}
+		text := p.sql[start.pos:p.tokens[p.pos-1].end]
+		if i, err := strconv.ParseInt(text, 10, 64); err == nil {
+			return i, nil
+		}
+		f, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return nil, parseError(p.sql, start.pos, "invalid number")
+		}
+		return f, nil
+	case t.kind == tokenParam:
+		p.advance()
+		if t.text == "?" {
+			if p.numbered {
+				return nil, parseError(p.sql, t.pos, "cannot mix ? and $n placeholders")
+			}
+			if p.next >= len(p.params) {
+				return nil, parseError(p.sql, t.pos, "not enough params")
+			}
+			p.next++
+			return p.params[p.next-1], nil
+		}
+		if p.next > 0 {
+			return nil, parseError(p.sql, t.pos, "cannot mix ? and $n placeholders")
+		}
+		p.numbered = true
+		n, _ := strconv.Atoi(t.text[1:])
+		if n < 1 || n > len(p.params) {
+			return nil, parseError(p.sql, t.pos, "param index out of range")
+		}
+		return p.params[n-1], nil
+	case p.acceptKeyword("null"):
+		return nil, nil
+	case p.acceptKeyword("true"):
+		return true, nil
+	case p.acceptKeyword("false"):
+		return false, nil
+	case p.isSymbol("("):
+		return nil, p.unsupported("subquery or expression")
+	}
+	return nil, p.errorf("expected value")
+}
@@ -0,0 +1,263 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LIKE_ESCAPE Contains、NotContains、StartsWith、EndsWith 转义 % _ 使用的字符，mysql 和 postgres 通用
+const LIKE_ESCAPE = "!"
+
+var likeEscaper = strings.NewReplacer(LIKE_ESCAPE, LIKE_ESCAPE+LIKE_ESCAPE, "%", LIKE_ESCAPE+"%", "_", LIKE_ESCAPE+"_")
+
+// EscapeLike 转义 like 中的通配符，转义后的值需要配合 escape '!' 使用
+func EscapeLike(value string) string {
+	return likeEscaper.Replace(value)
+}
+
+// likeSource 渲染 like 条件，not 时为 not like，ignoreCase 时 postgres 使用 ilike，mysql 两边使用 lower()，escape 时追加 escape '!'
+func likeSource(dialect Dialect, table, field string, pattern any, not, ignoreCase, escape, prepare bool) (string, []any, error) {
+	column, err := dialect.column(table, field)
+	if err != nil {
+		return "", nil, err
+	}
+	value := PLACE_HOLDER_GO
+	if !prepare {
+		value = literal(dialect, pattern)
+	}
+	operator := "like"
+	if ignoreCase {
+		if dialect.isPostgres() {
+			operator = "ilike"
+		} else {
+			column, value = "lower("+column+")", "lower("+value+")"
+		}
+	}
+	if not {
+		operator = "not " + operator
+	}
+	source := fmt.Sprintf("%s %s %s", column, operator, value)
+	if escape {
+		source += " escape '" + LIKE_ESCAPE + "'"
+	}
+	return source, []any{pattern}, nil
+}
+
+// patternQuery 根据 kind 创建 Contains、NotContains、StartsWith、EndsWith、Regexp 条件
+func patternQuery(kind, table, field, value string, ignoreCase bool) Query {
+	switch kind {
+	case DSL_CONTAINS:
+		return &ContainsQuery{table: table, field: field, value: value, ignoreCase: ignoreCase}
+	case DSL_NOT_CONTAINS:
+		return &NotContainsQuery{table: table, field: field, value: value, ignoreCase: ignoreCase}
+	case DSL_STARTS_WITH:
+		return &StartsWithQuery{table: table, field: field, value: value, ignoreCase: ignoreCase}
+	case DSL_ENDS_WITH:
+		return &EndsWithQuery{table: table, field: field, value: value, ignoreCase: ignoreCase}
+	}
+	return &RegexpQuery{table: table, field: field, value: value, ignoreCase: ignoreCase}
+}
+
+// ContainsQuery 包含，value 中的 % _ 按普通字符匹配
+type ContainsQuery struct {
+	table      string
+	field      string
+	value      string
+	ignoreCase bool
+}
+
+func NewContainsQuery(field, value string) *ContainsQuery {
+	return &ContainsQuery{field: field, value: value}
+}
+func NewContainsQueryWithTable(table, field, value string) *ContainsQuery {
+	return &ContainsQuery{table: table, field: field, value: value}
+}
+
+// IgnoreCase 不区分大小写
+func (q *ContainsQuery) IgnoreCase() *ContainsQuery {
+	q.ignoreCase = true
+	return q
+}
+
+func (q *ContainsQuery) Source(table string, prepare bool) (string, []any, error) {
+	return q.DialectSource(Dialect{}, table, prepare)
+}
+
+func (q *ContainsQuery) DialectSource(dialect Dialect, table string, prepare bool) (string, []any, error) {
+	if q.table != "" {
+		table = q.table
+	}
+	return likeSource(dialect, table, q.field, "%"+EscapeLike(q.value)+"%", false, q.ignoreCase, true, prepare)
+}
+
+// NotContainsQuery 不包含，value 中的 % _ 按普通字符匹配
+type NotContainsQuery struct {
+	table      string
+	field      string
+	value      string
+	ignoreCase bool
+}
+
+func NewNotContainsQuery(field, value string) *NotContainsQuery {
+	return &NotContainsQuery{field: field, value: value}
+}
+func NewNotContainsQueryWithTable(table, field, value string) *NotContainsQuery {
+	return &NotContainsQuery{table: table, field: field, value: value}
+}
+
+// IgnoreCase 不区分大小写
+func (q *NotContainsQuery) IgnoreCase() *NotContainsQuery {
+	q.ignoreCase = true
+	return q
+}
+
+func (q *NotContainsQuery) Source(table string, prepare bool) (string, []any, error) {
+	return q.DialectSource(Dialect{}, table, prepare)
+}
+
+func (q *NotContainsQuery) DialectSource(dialect Dialect, table string, prepare bool) (string, []any, error) {
+	if q.table != "" {
+		table = q.table
+	}
+	return likeSource(dialect, table, q.field, "%"+EscapeLike(q.value)+"%", true, q.ignoreCase, true, prepare)
+}
+
+// StartsWithQuery 以 value 开头，value 中的 % _ 按普通字符匹配
+type StartsWithQuery struct {
+	table      string
+	field      string
+	value      string
+	ignoreCase bool
+}
+
+func NewStartsWithQuery(field, value string) *StartsWithQuery {
+	return &StartsWithQuery{field: field, value: value}
+}
+func NewStartsWithQueryWithTable(table, field, value string) *StartsWithQuery {
+	return &StartsWithQuery{table: table, field: field, value: value}
+}
+
+// IgnoreCase 不区分大小写
+func (q *StartsWithQuery) IgnoreCase() *StartsWithQuery {
+	q.ignoreCase = true
+	return q
+}
+
+func (q *StartsWithQuery) Source(table string, prepare bool) (string, []any, error) {
+	return q.DialectSource(Dialect{}, table, prepare)
+}
+
+func (q *StartsWithQuery) DialectSource(dialect Dialect, table string, prepare bool) (string, []any, error) {
+	if q.table != "" {
+		table = q.table
+	}
+	return likeSource(dialect, table, q.field, EscapeLike(q.value)+"%", false, q.ignoreCase, true, prepare)
+}
+
+// EndsWithQuery 以 value 结尾，value 中的 % _ 按普通字符匹配
+type EndsWithQuery struct {
+	table      string
+	field      string
+	value      string
+	ignoreCase bool
+}
+
+func NewEndsWithQuery(field, value string) *EndsWithQuery {
+	return &EndsWithQuery{field: field, value: value}
+}
+func NewEndsWithQueryWithTable(table, field, value string) *EndsWithQuery {
+	return &EndsWithQuery{table: table, field: field, value: value}
+}
+
+// IgnoreCase 不区分大小写
+func (q *EndsWithQuery) IgnoreCase() *EndsWithQuery {
+	q.ignoreCase = true
+	return q
+}
+
+func (q *EndsWithQuery) Source(table string, prepare bool) (string, []any, error) {
+	return q.DialectSource(Dialect{}, table, prepare)
+}
+
+func (q *EndsWithQuery) DialectSource(dialect Dialect, table string, prepare bool) (string, []any, error) {
+	if q.table != "" {
+		table = q.table
+	}
+	return likeSource(dialect, table, q.field, "%"+EscapeLike(q.value), false, q.ignoreCase, true, prepare)
+}
+
+// ILikeQuery 不区分大小写的 like，value 为完整的匹配模式，postgres 为 ilike，mysql 为 lower() like lower()
+type ILikeQuery struct {
+	table string
+	field string
+	value any
+}
+
+func NewILikeQuery(field string, value any) *ILikeQuery {
+	return &ILikeQuery{field: field, value: value}
+}
+func NewILikeQueryWithTable(table, field string, value any) *ILikeQuery {
+	return &ILikeQuery{table: table, field: field, value: value}
+}
+
+func (q *ILikeQuery) Source(table string, prepare bool) (string, []any, error) {
+	return q.DialectSource(Dialect{}, table, prepare)
+}
+
+func (q *ILikeQuery) DialectSource(dialect Dialect, table string, prepare bool) (string, []any, error) {
+	if q.table != "" {
+		table = q.table
+	}
+	return likeSource(dialect, table, q.field, q.value, false, true, false, prepare)
+}
+
+// RegexpQuery 正则匹配，mysql 为 regexp，postgres 为 ~
+// IgnoreCase 时 mysql 为 regexp_like(字段, 值, 'i')，postgres 为 ~*
+type RegexpQuery struct {
+	table      string
+	field      string
+	value      string
+	ignoreCase bool
+}
+
+func NewRegexpQuery(field, value string) *RegexpQuery {
+	return &RegexpQuery{field: field, value: value}
+}
+func NewRegexpQueryWithTable(table, field, value string) *RegexpQuery {
+	return &RegexpQuery{table: table, field: field, value: value}
+}
+
+// IgnoreCase 不区分大小写
+func (q *RegexpQuery) IgnoreCase() *RegexpQuery {
+	q.ignoreCase = true
+	return q
+}
+
+func (q *RegexpQuery) Source(table string, prepare bool) (string, []any, error) {
+	return q.DialectSource(Dialect{}, table, prepare)
+}
+
+func (q *RegexpQuery) DialectSource(dialect Dialect, table string, prepare bool) (string, []any, error) {
+	if q.table != "" {
+		table = q.table
+	}
+	if dialect.isPostgres() {
+		operator := "~"
+		if q.ignoreCase {
+			operator = "~*"
+		}
+		return compareSource(dialect, table, q.field, operator, q.value, prepare)
+	}
+	if !q.ignoreCase {
+		return compareSource(dialect, table, q.field, "regexp", q.value, prepare)
+	}
+	column, err := dialect.column(table, q.field)
+	if err != nil {
+		return "", nil, err
+	}
+	value := PLACE_HOLDER_GO
+	if !prepare {
+		value = literal(dialect, q.value)
+	}
+	return fmt.Sprintf("regexp_like(%s, %s, 'i')", column, value), []any{q.value}, nil
+}
@@ -2,13 +2,101 @@ package generator
 
 import (
 	"bytes"
+	"database/sql/driver"
+	"errors"
 	"fmt"
+	"reflect"
+	"strings"
 )
 
 type Query interface {
 	Source(table string, prepare bool) (string, []any, error)
 }
 
+// compareSource 渲染 字段 操作符 值 形式的条件
+func compareSource(dialect Dialect, table, field, operator string, value any, prepare bool) (string, []any, error) {
+	column, err := dialect.column(table, field)
+	if err != nil {
+		return "", nil, err
+	}
+	if prepare {
+		return fmt.Sprintf("%s %s %s", column, operator, PLACE_HOLDER_GO), []any{value}, nil
+	}
+	return fmt.Sprintf("%s %s %s", column, operator, literal(dialect, value)), []any{value}, nil
+}
+
+// betweenSource 渲染 between 和 not between 条件
+func betweenSource(dialect Dialect, table, field, operator string, firstValue, secondValue any, prepare bool) (string, []any, error) {
+	column, err := dialect.column(table, field)
+	if err != nil {
+		return "", nil, err
+	}
+	param := []any{firstValue, secondValue}
+	if prepare {
+		return fmt.Sprintf("%s %s %s and %s", column, operator, PLACE_HOLDER_GO, PLACE_HOLDER_GO), param, nil
+	}
+	return fmt.Sprintf("%s %s %s and %s", column, operator, literal(dialect, firstValue), literal(dialect, secondValue)), param, nil
+}
+
+// inSource 渲染 in 和 not in 条件，没有值时 in 为永假，not in 为永真
+// array 时绑定一个数组参数，in 为 = ANY(?)，not in 为 <> ALL(?)
+func inSource(dialect Dialect, table, field, operator string, value []any, array, prepare bool) (string, []any, error) {
+	column, err := dialect.column(table, field)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(value) == 0 {
+		if operator == "in" {
+			return "1 = 0", nil, nil
+		}
+		return "1 = 1", nil, nil
+	}
+	if array {
+		function := "= ANY"
+		if operator == "not in" {
+			function = "<> ALL"
+		}
+		param := PgArray(value)
+		if prepare {
+			return fmt.Sprintf("%s %s(%s)", column, function, PLACE_HOLDER_GO), []any{param}, nil
+		}
+		return fmt.Sprintf("%s %s(%s)", column, function, literal(dialect, param)), []any{param}, nil
+	}
+	var sql bytes.Buffer
+	sql.WriteString(column + " " + operator + " (")
+	for k, v := range value {
+		if k != 0 {
+			sql.WriteString(" ,")
+		}
+		if prepare {
+			sql.WriteString(fmt.Sprintf(" %s", PLACE_HOLDER_GO))
+		} else {
+			sql.WriteString(fmt.Sprintf(" %s", literal(dialect, v)))
+		}
+	}
+	sql.WriteString(")")
+	return sql.String(), value, nil
+}
+
+// isNullValue nil、nil指针、Valid为false的 sql.NullXxx 都按null处理
+func isNullValue(value any) bool {
+	if value == nil {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice, reflect.Interface:
+		if v.IsNil() {
+			return true
+		}
+	}
+	if valuer, ok := value.(driver.Valuer); ok {
+		val, err := valuer.Value()
+		return err == nil && val == nil
+	}
+	return false
+}
+
 type NullQuery struct {
 	table string
 	field string
@@ -22,10 +110,18 @@ func NewNullQueryWithTable(table, field string) *NullQuery {
 }
 
 func (q *NullQuery) Source(table string, prepare bool) (string, []any, error) {
+	return q.DialectSource(Dialect{}, table, prepare)
+}
+
+func (q *NullQuery) DialectSource(dialect Dialect, table string, prepare bool) (string, []any, error) {
 	if q.table != "" {
 		table = q.table
 	}
-	return table + "." + q.field + " is null", nil, nil
+	column, err := dialect.column(table, q.field)
+	if err != nil {
+		return "", nil, err
+	}
+	return column + " is null", nil, nil
 }
 
 type NotNullQuery struct {
@@ -41,13 +137,22 @@ func NewNotNullQueryWithTable(table, field string) *NotNullQuery {
 }
 
 func (q *NotNullQuery) Source(table string, prepare bool) (string, []any, error) {
+	return q.DialectSource(Dialect{}, table, prepare)
+}
+
+func (q *NotNullQuery) DialectSource(dialect Dialect, table string, prepare bool) (string, []any, error) {
 	if q.table != "" {
 		table = q.table
 	}
-	return table + "." + q.field + " is not null", nil, nil
+	column, err := dialect.column(table, q.field)
+	if err != nil {
+		return "", nil, err
+	}
+	return column + " is not null", nil, nil
 }
 
 type BetweenQuery struct {
+	table       string
 	field       string
 	firstValue  any
 	secondValue any
@@ -56,20 +161,23 @@ type BetweenQuery struct {
 func NewBetweenQuery(field string, firstValue any, secondValue any) *BetweenQuery {
 	return &BetweenQuery{field: field, firstValue: firstValue, secondValue: secondValue}
 }
+func NewBetweenQueryWithTable(table, field string, firstValue any, secondValue any) *BetweenQuery {
+	return &BetweenQuery{table: table, field: field, firstValue: firstValue, secondValue: secondValue}
+}
 
 func (q *BetweenQuery) Source(table string, prepare bool) (string, []any, error) {
-	param := []any{q.firstValue, q.secondValue}
-	if prepare {
-		return fmt.Sprintf("%s.%s between %s and %s", table, q.field, PLACE_HOLDER_GO, PLACE_HOLDER_GO), param, nil
-	}
-	if IsNumberType(q.firstValue) {
-		return fmt.Sprintf("%s.%s between %v and %v", table, q.field, q.firstValue, q.secondValue), param, nil
-	} else {
-		return fmt.Sprintf("%s.%s between '%v' and '%v' ", table, q.field, q.firstValue, q.secondValue), param, nil
+	return q.DialectSource(Dialect{}, table, prepare)
+}
+
+func (q *BetweenQuery) DialectSource(dialect Dialect, table string, prepare bool) (string, []any, error) {
+	if q.table != "" {
+		table = q.table
 	}
+	return betweenSource(dialect, table, q.field, "between", q.firstValue, q.secondValue, prepare)
 }
 
 type NotBetweenQuery struct {
+	table       string
 	field       string
 	firstValue  any
 	secondValue any
@@ -78,17 +186,19 @@ type NotBetweenQuery struct {
 func NewNotBetweenQuery(field string, firstValue any, secondValue any) *NotBetweenQuery {
 	return &NotBetweenQuery{field: field, firstValue: firstValue, secondValue: secondValue}
 }
+func NewNotBetweenQueryWithTable(table, field string, firstValue any, secondValue any) *NotBetweenQuery {
+	return &NotBetweenQuery{table: table, field: field, firstValue: firstValue, secondValue: secondValue}
+}
 
 func (q *NotBetweenQuery) Source(table string, prepare bool) (string, []any, error) {
-	param := []any{q.firstValue, q.secondValue}
-	if prepare {
-		return fmt.Sprintf("%s.%s not between %s and %s", table, q.field, PLACE_HOLDER_GO, PLACE_HOLDER_GO), param, nil
-	}
-	if IsNumberType(q.firstValue) {
-		return fmt.Sprintf("%s.%s not between %v and %v", table, q.field, q.firstValue, q.secondValue), param, nil
-	} else {
-		return fmt.Sprintf("%s.%s not between '%v' and '%v' ", table, q.field, q.firstValue, q.secondValue), param, nil
+	return q.DialectSource(Dialect{}, table, prepare)
+}
+
+func (q *NotBetweenQuery) DialectSource(dialect Dialect, table string, prepare bool) (string, []any, error) {
+	if q.table != "" {
+		table = q.table
 	}
+	return betweenSource(dialect, table, q.field, "not between", q.firstValue, q.secondValue, prepare)
 }
 
 type EqualQuery struct {
@@ -105,20 +215,21 @@ func NewEqualQueryWithTable(table, field string, value any) *EqualQuery {
 }
 
 func (q *EqualQuery) Source(table string, prepare bool) (string, []any, error) {
+	return q.DialectSource(Dialect{}, table, prepare)
+}
+
+func (q *EqualQuery) DialectSource(dialect Dialect, table string, prepare bool) (string, []any, error) {
 	if q.table != "" {
 		table = q.table
 	}
-	if prepare {
-		return fmt.Sprintf("%s.%s = %s", table, q.field, PLACE_HOLDER_GO), []any{q.value}, nil
-	}
-	if IsNumberType(q.value) {
-		return fmt.Sprintf("%s.%s = %v", table, q.field, q.value), []any{q.value}, nil
-	} else {
-		return fmt.Sprintf("%s.%s = '%v'", table, q.field, q.value), []any{q.value}, nil
+	if isNullValue(q.value) {
+		return NewNullQueryWithTable(table, q.field).DialectSource(dialect, table, prepare)
 	}
+	return compareSource(dialect, table, q.field, "=", q.value, prepare)
 }
 
 type NotEqualQuery struct {
+	table string
 	field string
 	value any
 }
@@ -126,77 +237,222 @@ type NotEqualQuery struct {
 func NewNotEqualQuery(field string, value any) *NotEqualQuery {
 	return &NotEqualQuery{field: field, value: value}
 }
+func NewNotEqualQueryWithTable(table, field string, value any) *NotEqualQuery {
+	return &NotEqualQuery{table: table, field: field, value: value}
+}
 
 func (q *NotEqualQuery) Source(table string, prepare bool) (string, []any, error) {
-	if prepare {
-		return fmt.Sprintf("%s.%s != %s", table, q.field, PLACE_HOLDER_GO), []any{q.value}, nil
+	return q.DialectSource(Dialect{}, table, prepare)
+}
+
+func (q *NotEqualQuery) DialectSource(dialect Dialect, table string, prepare bool) (string, []any, error) {
+	if q.table != "" {
+		table = q.table
 	}
-	if IsNumberType(q.value) {
-		return fmt.Sprintf("%s.%s != %v", table, q.field, q.value), []any{q.value}, nil
-	} else {
-		return fmt.Sprintf("%s.%s != '%v'", table, q.field, q.value), []any{q.value}, nil
+	if isNullValue(q.value) {
+		return NewNotNullQueryWithTable(table, q.field).DialectSource(dialect, table, prepare)
 	}
+	return compareSource(dialect, table, q.field, "!=", q.value, prepare)
+}
+
+// NullSafeEqualQuery null安全的等于，值为null时匹配null，mysql 为 <=>，postgres 为 is not distinct from
+type NullSafeEqualQuery struct {
+	table string
+	field string
+	value any
+}
+
+func NewNullSafeEqualQuery(field string, value any) *NullSafeEqualQuery {
+	return &NullSafeEqualQuery{field: field, value: value}
+}
+func NewNullSafeEqualQueryWithTable(table, field string, value any) *NullSafeEqualQuery {
+	return &NullSafeEqualQuery{table: table, field: field, value: value}
+}
+
+func (q *NullSafeEqualQuery) Source(table string, prepare bool) (string, []any, error) {
+	return q.DialectSource(Dialect{}, table, prepare)
+}
+
+func (q *NullSafeEqualQuery) DialectSource(dialect Dialect, table string, prepare bool) (string, []any, error) {
+	if q.table != "" {
+		table = q.table
+	}
+	if dialect.isPostgres() {
+		return compareSource(dialect, table, q.field, "is not distinct from", q.value, prepare)
+	}
+	return compareSource(dialect, table, q.field, "<=>", q.value, prepare)
+}
+
+// NullSafeNotEqualQuery null安全的不等于，mysql 为 not (<=>)，postgres 为 is distinct from
+type NullSafeNotEqualQuery struct {
+	table string
+	field string
+	value any
+}
+
+func NewNullSafeNotEqualQuery(field string, value any) *NullSafeNotEqualQuery {
+	return &NullSafeNotEqualQuery{field: field, value: value}
+}
+func NewNullSafeNotEqualQueryWithTable(table, field string, value any) *NullSafeNotEqualQuery {
+	return &NullSafeNotEqualQuery{table: table, field: field, value: value}
+}
+
+func (q *NullSafeNotEqualQuery) Source(table string, prepare bool) (string, []any, error) {
+	return q.DialectSource(Dialect{}, table, prepare)
+}
+
+func (q *NullSafeNotEqualQuery) DialectSource(dialect Dialect, table string, prepare bool) (string, []any, error) {
+	if q.table != "" {
+		table = q.table
+	}
+	if dialect.isPostgres() {
+		return compareSource(dialect, table, q.field, "is distinct from", q.value, prepare)
+	}
+	source, params, err := compareSource(dialect, table, q.field, "<=>", q.value, prepare)
+	if err != nil {
+		return "", nil, err
+	}
+	return "not (" + source + ")", params, nil
 }
 
 type InQuery struct {
+	table string
 	field string
 	value []any
+	array *bool //是否使用数组参数，为空时按 Dialect 的 ArrayIn
 }
 
 func NewInQuery(field string, value []any) *InQuery {
 	return &InQuery{field: field, value: value}
 }
+func NewInQueryWithTable(table, field string, value []any) *InQuery {
+	return &InQuery{table: table, field: field, value: value}
+}
 
 func (q *InQuery) Source(table string, prepare bool) (string, []any, error) {
-	var sql bytes.Buffer
-	sql.WriteString(table + "." + q.field + " in (")
-	for k, v := range q.value {
-		if k != 0 {
-			sql.WriteString(" ,")
-		}
-		if prepare {
-			sql.WriteString(fmt.Sprintf(" %s", PLACE_HOLDER_GO))
-		} else if IsNumberType(q.value) {
-			sql.WriteString(fmt.Sprintf(" %v ", v))
-		} else {
-			sql.WriteString(fmt.Sprintf(" '%v' ", v))
-		}
+	return q.DialectSource(Dialect{}, table, prepare)
+}
 
+func (q *InQuery) DialectSource(dialect Dialect, table string, prepare bool) (string, []any, error) {
+	if q.table != "" {
+		table = q.table
 	}
-	sql.WriteString(")")
-	return sql.String(), q.value, nil
+	return inSource(dialect, table, q.field, "in", q.value, dialect.arrayIn(q.array), prepare)
+}
+
+// Array postgres 是否绑定一个数组参数 = ANY(?)，优先于 Generator 的 ArrayIn，mysql 不生效
+func (q *InQuery) Array(array bool) *InQuery {
+	q.array = &array
+	return q
 }
 
 type NotInQuery struct {
+	table string
 	field string
 	value []any
+	array *bool //是否使用数组参数，为空时按 Dialect 的 ArrayIn
 }
 
 func NewNotInQuery(field string, value []any) *NotInQuery {
 	return &NotInQuery{field: field, value: value}
 }
+func NewNotInQueryWithTable(table, field string, value []any) *NotInQuery {
+	return &NotInQuery{table: table, field: field, value: value}
+}
 
 func (q *NotInQuery) Source(table string, prepare bool) (string, []any, error) {
-	var sql bytes.Buffer
-	sql.WriteString(table + "." + q.field + " not in (")
-	for k, v := range q.value {
-		if k != 0 {
-			sql.WriteString(" ,")
+	return q.DialectSource(Dialect{}, table, prepare)
+}
+
+func (q *NotInQuery) DialectSource(dialect Dialect, table string, prepare bool) (string, []any, error) {
+	if q.table != "" {
+		table = q.table
+	}
+	return inSource(dialect, table, q.field, "not in", q.value, dialect.arrayIn(q.array), prepare)
+}
+
+// Array postgres 是否绑定一个数组参数 <> ALL(?)，优先于 Generator 的 ArrayIn，mysql 不生效
+func (q *NotInQuery) Array(array bool) *NotInQuery {
+	q.array = &array
+	return q
+}
+
+// TupleInQuery 多个字段的 in，用于联合主键，(a, b) in ((?, ?), (?, ?))
+// Expand 后展开为 (a = ? and b = ?) or (a = ? and b = ?)，用于不支持行构造器的数据库
+type TupleInQuery struct {
+	table  string
+	fields []string
+	values [][]any
+	expand bool
+}
+
+func NewTupleInQuery(fields []string, values [][]any) *TupleInQuery {
+	return &TupleInQuery{fields: fields, values: values}
+}
+func NewTupleInQueryWithTable(table string, fields []string, values [][]any) *TupleInQuery {
+	return &TupleInQuery{table: table, fields: fields, values: values}
+}
+
+// Expand 展开为 or 连接的 and 条件
+func (q *TupleInQuery) Expand() *TupleInQuery {
+	q.expand = true
+	return q
+}
+
+func (q *TupleInQuery) Source(table string, prepare bool) (string, []any, error) {
+	return q.DialectSource(Dialect{}, table, prepare)
+}
+
+func (q *TupleInQuery) DialectSource(dialect Dialect, table string, prepare bool) (string, []any, error) {
+	if q.table != "" {
+		table = q.table
+	}
+	if len(q.fields) == 0 {
+		return "", nil, errors.New("tuple in fields cannot be empty")
+	}
+	if len(q.values) == 0 {
+		return "1 = 0", nil, nil
+	}
+	columns := make([]string, 0, len(q.fields))
+	for _, field := range q.fields {
+		column, err := dialect.column(table, field)
+		if err != nil {
+			return "", nil, err
 		}
-		if prepare {
-			sql.WriteString(fmt.Sprintf(" %s", PLACE_HOLDER_GO))
-		} else if IsNumberType(q.value) {
-			sql.WriteString(fmt.Sprintf(" %v ", v))
+		columns = append(columns, column)
+	}
+	params := make([]any, 0, len(q.fields)*len(q.values))
+	rows := make([]string, 0, len(q.values))
+	for _, row := range q.values {
+		if len(row) != len(q.fields) {
+			return "", nil, fmt.Errorf("tuple in row %v does not match fields %v", row, q.fields)
+		}
+		items := make([]string, 0, len(row))
+		for i, v := range row {
+			value := PLACE_HOLDER_GO
+			if !prepare {
+				value = literal(dialect, v)
+			}
+			if q.expand {
+				value = columns[i] + " = " + value
+			}
+			items = append(items, value)
+		}
+		params = append(params, row...)
+		if q.expand {
+			rows = append(rows, "("+strings.Join(items, " and ")+")")
 		} else {
-			sql.WriteString(fmt.Sprintf(" '%v' ", v))
+			rows = append(rows, "("+strings.Join(items, ", ")+")")
 		}
-
 	}
-	sql.WriteString(")")
-	return sql.String(), q.value, nil
+	if q.expand {
+		return "(" + strings.Join(rows, " or ") + ")", params, nil
+	}
+	return "(" + strings.Join(columns, ", ") + ") in (" + strings.Join(rows, ", ") + ")", params, nil
 }
 
 type LikeQuery struct {
+	table string
 	field string
 	value any
 }
@@ -204,19 +460,23 @@ type LikeQuery struct {
 func NewLikeQuery(field string, value any) *LikeQuery {
 	return &LikeQuery{field: field, value: value}
 }
+func NewLikeQueryWithTable(table, field string, value any) *LikeQuery {
+	return &LikeQuery{table: table, field: field, value: value}
+}
 
 func (q *LikeQuery) Source(table string, prepare bool) (string, []any, error) {
-	if prepare {
-		return fmt.Sprintf("%s.%s like %s", table, q.field, PLACE_HOLDER_GO), []any{q.value}, nil
-	}
-	if IsNumberType(q.value) {
-		return fmt.Sprintf("%s.%s like '%v'", table, q.field, q.value), []any{q.value}, nil
-	} else {
-		return fmt.Sprintf("%s.%s like '%v'", table, q.field, q.value), []any{q.value}, nil
+	return q.DialectSource(Dialect{}, table, prepare)
+}
+
+func (q *LikeQuery) DialectSource(dialect Dialect, table string, prepare bool) (string, []any, error) {
+	if q.table != "" {
+		table = q.table
 	}
+	return compareSource(dialect, table, q.field, "like", q.value, prepare)
 }
 
 type NotLikeQuery struct {
+	table string
 	field string
 	value any
 }
@@ -224,19 +484,23 @@ type NotLikeQuery struct {
 func NewNotLikeQuery(field string, value any) *NotLikeQuery {
 	return &NotLikeQuery{field: field, value: value}
 }
+func NewNotLikeQueryWithTable(table, field string, value any) *NotLikeQuery {
+	return &NotLikeQuery{table: table, field: field, value: value}
+}
 
 func (q *NotLikeQuery) Source(table string, prepare bool) (string, []any, error) {
-	if prepare {
-		return fmt.Sprintf("%s.%s not like %s", table, q.field, PLACE_HOLDER_GO), []any{q.value}, nil
-	}
-	if IsNumberType(q.value) {
-		return fmt.Sprintf("%s.%s not like %v", table, q.field, q.value), []any{q.value}, nil
-	} else {
-		return fmt.Sprintf("%s.%s not like '%v'", table, q.field, q.value), []any{q.value}, nil
+	return q.DialectSource(Dialect{}, table, prepare)
+}
+
+func (q *NotLikeQuery) DialectSource(dialect Dialect, table string, prepare bool) (string, []any, error) {
+	if q.table != "" {
+		table = q.table
 	}
+	return compareSource(dialect, table, q.field, "not like", q.value, prepare)
 }
 
 type GreaterThanQuery struct {
+	table string
 	field string
 	value any
 }
@@ -244,19 +508,23 @@ type GreaterThanQuery struct {
 func NewGreaterThanQuery(field string, value any) *GreaterThanQuery {
 	return &GreaterThanQuery{field: field, value: value}
 }
+func NewGreaterThanQueryWithTable(table, field string, value any) *GreaterThanQuery {
+	return &GreaterThanQuery{table: table, field: field, value: value}
+}
 
 func (q *GreaterThanQuery) Source(table string, prepare bool) (string, []any, error) {
-	if prepare {
-		return fmt.Sprintf("%s.%s > %s", table, q.field, PLACE_HOLDER_GO), []any{q.value}, nil
-	}
-	if IsNumberType(q.value) {
-		return fmt.Sprintf("%s.%s > %v", table, q.field, q.value), []any{q.value}, nil
-	} else {
-		return fmt.Sprintf("%s.%s > '%v'", table, q.field, q.value), []any{q.value}, nil
+	return q.DialectSource(Dialect{}, table, prepare)
+}
+
+func (q *GreaterThanQuery) DialectSource(dialect Dialect, table string, prepare bool) (string, []any, error) {
+	if q.table != "" {
+		table = q.table
 	}
+	return compareSource(dialect, table, q.field, ">", q.value, prepare)
 }
 
 type GreaterThanOrEqualQuery struct {
+	table string
 	field string
 	value any
 }
@@ -264,19 +532,23 @@ type GreaterThanOrEqualQuery struct {
 func NewGreaterThanOrEqualQuery(field string, value any) *GreaterThanOrEqualQuery {
 	return &GreaterThanOrEqualQuery{field: field, value: value}
 }
+func NewGreaterThanOrEqualQueryWithTable(table, field string, value any) *GreaterThanOrEqualQuery {
+	return &GreaterThanOrEqualQuery{table: table, field: field, value: value}
+}
 
 func (q *GreaterThanOrEqualQuery) Source(table string, prepare bool) (string, []any, error) {
-	if prepare {
-		return fmt.Sprintf("%s.%s >= %s", table, q.field, PLACE_HOLDER_GO), []any{q.value}, nil
-	}
-	if IsNumberType(q.value) {
-		return fmt.Sprintf("%s.%s >= %v", table, q.field, q.value), []any{q.value}, nil
-	} else {
-		return fmt.Sprintf("%s.%s >= '%v'", table, q.field, q.value), []any{q.value}, nil
+	return q.DialectSource(Dialect{}, table, prepare)
+}
+
+func (q *GreaterThanOrEqualQuery) DialectSource(dialect Dialect, table string, prepare bool) (string, []any, error) {
+	if q.table != "" {
+		table = q.table
 	}
+	return compareSource(dialect, table, q.field, ">=", q.value, prepare)
 }
 
 type LessThanQuery struct {
+	table string
 	field string
 	value any
 }
@@ -284,19 +556,23 @@ type LessThanQuery struct {
 func NewLessThanQuery(field string, value any) *LessThanQuery {
 	return &LessThanQuery{field: field, value: value}
 }
+func NewLessThanQueryWithTable(table, field string, value any) *LessThanQuery {
+	return &LessThanQuery{table: table, field: field, value: value}
+}
 
 func (q *LessThanQuery) Source(table string, prepare bool) (string, []any, error) {
-	if prepare {
-		return fmt.Sprintf("%s.%s < %s", table, q.field, PLACE_HOLDER_GO), []any{q.value}, nil
-	}
-	if IsNumberType(q.value) {
-		return fmt.Sprintf("%s.%s < %v", table, q.field, q.value), []any{q.value}, nil
-	} else {
-		return fmt.Sprintf("%s.%s < '%v'", table, q.field, q.value), []any{q.value}, nil
+	return q.DialectSource(Dialect{}, table, prepare)
+}
+
+func (q *LessThanQuery) DialectSource(dialect Dialect, table string, prepare bool) (string, []any, error) {
+	if q.table != "" {
+		table = q.table
 	}
+	return compareSource(dialect, table, q.field, "<", q.value, prepare)
 }
 
 type LessThanOrEqualQuery struct {
+	table string
 	field string
 	value any
 }
@@ -304,19 +580,24 @@ type LessThanOrEqualQuery struct {
 func NewLessThanOrEqualQuery(field string, value any) *LessThanOrEqualQuery {
 	return &LessThanOrEqualQuery{field: field, value: value}
 }
+func NewLessThanOrEqualQueryWithTable(table, field string, value any) *LessThanOrEqualQuery {
+	return &LessThanOrEqualQuery{table: table, field: field, value: value}
+}
 
 func (q *LessThanOrEqualQuery) Source(table string, prepare bool) (string, []any, error) {
-	if prepare {
-		return fmt.Sprintf("%s.%s <= %s", table, q.field, PLACE_HOLDER_GO), []any{q.value}, nil
-	}
-	if IsNumberType(q.value) {
-		return fmt.Sprintf("%s.%s <= %v", table, q.field, q.value), []any{q.value}, nil
-	} else {
-		return fmt.Sprintf("%s.%s <= '%v'", table, q.field, q.value), []any{q.value}, nil
+	return q.DialectSource(Dialect{}, table, prepare)
+}
+
+func (q *LessThanOrEqualQuery) DialectSource(dialect Dialect, table string, prepare bool) (string, []any, error) {
+	if q.table != "" {
+		table = q.table
 	}
+	return compareSource(dialect, table, q.field, "<=", q.value, prepare)
 }
 
+// 字段与字段比较的条件，字段可以是 table.column 形式，未指定表名的字段使用 WithTable 的表名，没有时使用默认表名
 type FieldEqualQuery struct {
+	table       string
 	firstField  string
 	secondField string
 }
@@ -324,12 +605,23 @@ type FieldEqualQuery struct {
 func NewFieldEqualQuery(firstField, secondField string) *FieldEqualQuery {
 	return &FieldEqualQuery{firstField: firstField, secondField: secondField}
 }
+func NewFieldEqualQueryWithTable(table, firstField, secondField string) *FieldEqualQuery {
+	return &FieldEqualQuery{table: table, firstField: firstField, secondField: secondField}
+}
 
 func (q *FieldEqualQuery) Source(table string, prepare bool) (string, []any, error) {
-	return fmt.Sprintf("%s = %s", q.firstField, q.secondField), []any{}, nil
+	return q.DialectSource(Dialect{}, table, prepare)
+}
+
+func (q *FieldEqualQuery) DialectSource(dialect Dialect, table string, prepare bool) (string, []any, error) {
+	if q.table != "" {
+		table = q.table
+	}
+	return fieldSource(dialect, table, q.firstField, "=", q.secondField)
 }
 
 type FieldNotEqualQuery struct {
+	table       string
 	firstField  string
 	secondField string
 }
@@ -337,13 +629,134 @@ type FieldNotEqualQuery struct {
 func NewFieldNotEqualQuery(firstField, secondField string) *FieldNotEqualQuery {
 	return &FieldNotEqualQuery{firstField: firstField, secondField: secondField}
 }
+func NewFieldNotEqualQueryWithTable(table, firstField, secondField string) *FieldNotEqualQuery {
+	return &FieldNotEqualQuery{table: table, firstField: firstField, secondField: secondField}
+}
 
 func (q *FieldNotEqualQuery) Source(table string, prepare bool) (string, []any, error) {
-	return fmt.Sprintf("%s != %s", q.firstField, q.secondField), []any{}, nil
+	return q.DialectSource(Dialect{}, table, prepare)
+}
+
+func (q *FieldNotEqualQuery) DialectSource(dialect Dialect, table string, prepare bool) (string, []any, error) {
+	if q.table != "" {
+		table = q.table
+	}
+	return fieldSource(dialect, table, q.firstField, "!=", q.secondField)
 }
 
+type FieldGreaterThanQuery struct {
+	table       string
+	firstField  string
+	secondField string
+}
+
+func NewFieldGreaterThanQuery(firstField, secondField string) *FieldGreaterThanQuery {
+	return &FieldGreaterThanQuery{firstField: firstField, secondField: secondField}
+}
+func NewFieldGreaterThanQueryWithTable(table, firstField, secondField string) *FieldGreaterThanQuery {
+	return &FieldGreaterThanQuery{table: table, firstField: firstField, secondField: secondField}
+}
+
+func (q *FieldGreaterThanQuery) Source(table string, prepare bool) (string, []any, error) {
+	return q.DialectSource(Dialect{}, table, prepare)
+}
+
+func (q *FieldGreaterThanQuery) DialectSource(dialect Dialect, table string, prepare bool) (string, []any, error) {
+	if q.table != "" {
+		table = q.table
+	}
+	return fieldSource(dialect, table, q.firstField, ">", q.secondField)
+}
+
+type FieldGreaterThanOrEqualQuery struct {
+	table       string
+	firstField  string
+	secondField string
+}
+
+func NewFieldGreaterThanOrEqualQuery(firstField, secondField string) *FieldGreaterThanOrEqualQuery {
+	return &FieldGreaterThanOrEqualQuery{firstField: firstField, secondField: secondField}
+}
+func NewFieldGreaterThanOrEqualQueryWithTable(table, firstField, secondField string) *FieldGreaterThanOrEqualQuery {
+	return &FieldGreaterThanOrEqualQuery{table: table, firstField: firstField, secondField: secondField}
+}
+
+func (q *FieldGreaterThanOrEqualQuery) Source(table string, prepare bool) (string, []any, error) {
+	return q.DialectSource(Dialect{}, table, prepare)
+}
+
+func (q *FieldGreaterThanOrEqualQuery) DialectSource(dialect Dialect, table string, prepare bool) (string, []any, error) {
+	if q.table != "" {
+		table = q.table
+	}
+	return fieldSource(dialect, table, q.firstField, ">=", q.secondField)
+}
+
+type FieldLessThanQuery struct {
+	table       string
+	firstField  string
+	secondField string
+}
+
+func NewFieldLessThanQuery(firstField, secondField string) *FieldLessThanQuery {
+	return &FieldLessThanQuery{firstField: firstField, secondField: secondField}
+}
+func NewFieldLessThanQueryWithTable(table, firstField, secondField string) *FieldLessThanQuery {
+	return &FieldLessThanQuery{table: table, firstField: firstField, secondField: secondField}
+}
+
+func (q *FieldLessThanQuery) Source(table string, prepare bool) (string, []any, error) {
+	return q.DialectSource(Dialect{}, table, prepare)
+}
+
+func (q *FieldLessThanQuery) DialectSource(dialect Dialect, table string, prepare bool) (string, []any, error) {
+	if q.table != "" {
+		table = q.table
+	}
+	return fieldSource(dialect, table, q.firstField, "<", q.secondField)
+}
+
+type FieldLessThanOrEqualQuery struct {
+	table       string
+	firstField  string
+	secondField string
+}
+
+func NewFieldLessThanOrEqualQuery(firstField, secondField string) *FieldLessThanOrEqualQuery {
+	return &FieldLessThanOrEqualQuery{firstField: firstField, secondField: secondField}
+}
+func NewFieldLessThanOrEqualQueryWithTable(table, firstField, secondField string) *FieldLessThanOrEqualQuery {
+	return &FieldLessThanOrEqualQuery{table: table, firstField: firstField, secondField: secondField}
+}
+
+func (q *FieldLessThanOrEqualQuery) Source(table string, prepare bool) (string, []any, error) {
+	return q.DialectSource(Dialect{}, table, prepare)
+}
+
+func (q *FieldLessThanOrEqualQuery) DialectSource(dialect Dialect, table string, prepare bool) (string, []any, error) {
+	if q.table != "" {
+		table = q.table
+	}
+	return fieldSource(dialect, table, q.firstField, "<=", q.secondField)
+}
+
+// fieldSource 渲染字段与字段比较的条件
+func fieldSource(dialect Dialect, table, firstField, operator, secondField string) (string, []any, error) {
+	first, err := dialect.column(table, firstField)
+	if err != nil {
+		return "", nil, err
+	}
+	second, err := dialect.column(table, secondField)
+	if err != nil {
+		return "", nil, err
+	}
+	return fmt.Sprintf("%s %s %s", first, operator, second), []any{}, nil
+}
+
+// BoolQuery 组合条件，And 中的条件用 and 连接，Or 中的条件用 or 连接后作为一个整体再和 And 的条件 and 连接
 type BoolQuery struct {
-	query []Query
+	query   []Query
+	orQuery []Query
 }
 
 func NewBoolQuery() *BoolQuery {
@@ -352,27 +765,60 @@ func NewBoolQuery() *BoolQuery {
 	}
 }
 
+// And 添加用 and 连接的条件，nil 条件忽略
 func (q *BoolQuery) And(queries ...Query) *BoolQuery {
-	q.query = append(q.query, queries...)
+	q.query = append(q.query, presentQueries(queries)...)
+	return q
+}
+
+// Or 添加用 or 连接的条件，nil 条件忽略
+func (q *BoolQuery) Or(queries ...Query) *BoolQuery {
+	q.orQuery = append(q.orQuery, presentQueries(queries)...)
 	return q
 }
 
 func (q *BoolQuery) Source(table string, prepare bool) (string, []any, error) {
-	params := make([]any, 0)
-	if q.query == nil || len(q.query) <= 0 {
-		return "", params, nil
+	return q.DialectSource(Dialect{}, table, prepare)
+}
+
+func (q *BoolQuery) DialectSource(dialect Dialect, table string, prepare bool) (string, []any, error) {
+	if len(q.query) == 0 && len(q.orQuery) == 0 {
+		return "", make([]any, 0), nil
+	}
+	if len(q.query) == 0 {
+		return joinQueries(q.orQuery, "or", dialect, table, prepare)
+	}
+	queries := q.query
+	if len(q.orQuery) > 0 {
+		queries = append(cloneSlice(q.query), NewBoolQuery().Or(q.orQuery...))
 	}
+	return joinQueries(queries, "and", dialect, table, prepare)
+}
+
+// joinQueries 用 and/or 连接多个条件，结果带括号，空条件(如没有子条件的 BoolQuery)忽略，全部为空时返回空
+func joinQueries(queries []Query, connector string, dialect Dialect, table string, prepare bool) (string, []any, error) {
+	params := make([]any, 0)
 	var sql bytes.Buffer
-	sql.WriteString("(")
-	if q.query != nil || len(q.query) > 0 {
-		for k, query := range q.query {
-			if k != 0 {
-				sql.WriteString(" and")
-			}
-			source, param, _ := query.Source(table, prepare)
-			params = append(params, param...)
-			sql.WriteString(" " + source + " ")
+	n := 0
+	for _, query := range queries {
+		source, param, err := querySource(query, dialect, table, prepare)
+		if err != nil {
+			return "", nil, err
+		}
+		if source == "" {
+			continue
 		}
+		if n == 0 {
+			sql.WriteString("(")
+		} else {
+			sql.WriteString(" " + connector)
+		}
+		params = append(params, param...)
+		sql.WriteString(" " + source + " ")
+		n++
+	}
+	if n == 0 {
+		return "", params, nil
 	}
 	sql.WriteString(")")
 	return sql.String(), params, nil
@@ -0,0 +1,238 @@
+package generator
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+)
+
+const (
+	trashedExclude = iota // 只查询未删除的数据
+	trashedWith           // 查询全部数据
+	trashedOnly           // 只查询已删除的数据
+)
+
+var softDeletes sync.Map  // 表名 -> 软删除字段
+var tenantTables sync.Map // 表名 -> 租户字段
+
+type tenantKey struct{}
+
+// RegisterSoftDelete 注册软删除的表，查询、统计、关联时自动过滤 column is null 的数据，删除时改为 update column = now()
+func RegisterSoftDelete(table, column string) {
+	softDeletes.Store(table, column)
+}
+
+// SoftDeleteColumn 注册的软删除字段，没有注册时为空
+func SoftDeleteColumn(table string) string {
+	if column, ok := softDeletes.Load(table); ok {
+		return column.(string)
+	}
+	return ""
+}
+
+// SoftDelete 主表的软删除字段，优先于 RegisterSoftDelete 注册的字段
+func (s *Generator) SoftDelete(column string) *Generator {
+	s.softDelete = column
+	return s
+}
+
+// WithTrashed 查询时包括已软删除的数据
+func (s *Generator) WithTrashed() *Generator {
+	s.trashed = trashedWith
+	return s
+}
+
+// OnlyTrashed 查询时只查已软删除的数据
+func (s *Generator) OnlyTrashed() *Generator {
+	s.trashed = trashedOnly
+	return s
+}
+
+// ForceDelete 软删除的表也执行物理删除
+func (s *Generator) ForceDelete() *Generator {
+	s.forceDelete = true
+	return s
+}
+
+func (s *Generator) softDeleteColumn(tableName string) string {
+	if tableName == s.tableName && s.softDelete != "" {
+		return s.softDelete
+	}
+	return SoftDeleteColumn(tableName)
+}
+
+// RegisterTenant 注册按租户隔离的表，查询、统计、关联、更新、删除时自动加上 column = 租户，插入时自动写入租户
+// 没有设置租户的 Generator 生成这些表的sql时返回错误
+func RegisterTenant(table, column string) {
+	tenantTables.Store(table, column)
+}
+
+// TenantColumn 注册的租户字段，没有注册时为空
+func TenantColumn(table string) string {
+	if column, ok := tenantTables.Load(table); ok {
+		return column.(string)
+	}
+	return ""
+}
+
+// WithTenant 把租户放到 context 中，配合 Generator.WithContext 使用
+func WithTenant(ctx context.Context, tenant any) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFromContext 获取 context 中的租户
+func TenantFromContext(ctx context.Context) (any, bool) {
+	tenant := ctx.Value(tenantKey{})
+	return tenant, tenant != nil
+}
+
+// Tenant 设置租户
+func (s *Generator) Tenant(tenant any) *Generator {
+	s.tenant = tenant
+	s.hasTenant = true
+	return s
+}
+
+// WithContext 使用 context 中的租户，context 中没有租户时不做修改
+func (s *Generator) WithContext(ctx context.Context) *Generator {
+	if tenant, ok := TenantFromContext(ctx); ok {
+		s.Tenant(tenant)
+	}
+	return s
+}
+
+// IgnoreTenant 不附加租户条件，用于跨租户的后台任务
+func (s *Generator) IgnoreTenant() *Generator {
+	s.ignoreTenant = true
+	return s
+}
+
+// inheritScope 派生表使用外层的方言和租户
+func (s *Generator) inheritScope(parent *Generator) {
+	s.dialect = parent.dialect
+	if !s.hasTenant {
+		s.tenant, s.hasTenant = parent.tenant, parent.hasTenant
+	}
+	s.ignoreTenant = s.ignoreTenant || parent.ignoreTenant
+}
+
+// tenantScope 租户条件，不是租户表时返回nil
+func (s *Generator) tenantScope(tableName, table string) (Query, error) {
+	column := TenantColumn(tableName)
+	if column == "" || s.ignoreTenant {
+		return nil, nil
+	}
+	if !s.hasTenant {
+		return nil, fmt.Errorf("table %s requires tenant", tableName)
+	}
+	return NewEqualQueryWithTable(table, column, s.tenant), nil
+}
+
+// scopes 查询、统计、关联时自动附加的条件，table 为表名或别名
+func (s *Generator) scopes(tableName, table string) ([]Query, error) {
+	queries := make([]Query, 0)
+	tenant, err := s.tenantScope(tableName, table)
+	if err != nil {
+		return nil, err
+	}
+	if tenant != nil {
+		queries = append(queries, tenant)
+	}
+	if column := s.softDeleteColumn(tableName); column != "" {
+		switch s.trashed {
+		case trashedExclude:
+			queries = append(queries, NewNullQueryWithTable(table, column))
+		case trashedOnly:
+			queries = append(queries, NewNotNullQueryWithTable(table, column))
+		}
+	}
+	return queries, nil
+}
+
+// writeScopes 更新、删除时自动附加的条件
+func (s *Generator) writeScopes() ([]Query, error) {
+	queries := make([]Query, 0)
+	tenant, err := s.tenantScope(s.tableName, s.physicalTable())
+	if err != nil {
+		return nil, err
+	}
+	if tenant != nil {
+		queries = append(queries, tenant)
+	}
+	return queries, nil
+}
+
+// tenantInserts 插入的数据写入租户，不修改传入的map，数据中已有不同的租户时返回错误
+func (s *Generator) tenantInserts() (map[string]any, []map[string]any, error) {
+	column := TenantColumn(s.tableName)
+	if column == "" || s.ignoreTenant {
+		return s.insert, s.inserts, nil
+	}
+	if !s.hasTenant {
+		return nil, nil, fmt.Errorf("table %s requires tenant", s.tableName)
+	}
+	stamp := func(m map[string]any) (map[string]any, error) {
+		if v, ok := m[column]; ok && fmt.Sprint(v) != fmt.Sprint(s.tenant) {
+			return nil, fmt.Errorf("%s %v does not match tenant %v", column, v, s.tenant)
+		}
+		c := cloneMap(m)
+		if c == nil {
+			c = make(map[string]any)
+		}
+		c[column] = s.tenant
+		return c, nil
+	}
+	if len(s.inserts) > 0 {
+		inserts := make([]map[string]any, 0, len(s.inserts))
+		for _, m := range s.inserts {
+			c, err := stamp(m)
+			if err != nil {
+				return nil, nil, err
+			}
+			inserts = append(inserts, c)
+		}
+		return nil, inserts, nil
+	}
+	insert, err := stamp(s.insert)
+	return insert, nil, err
+}
+
+// checkTenantUpdate 不允许把数据更新到其他租户
+func (s *Generator) checkTenantUpdate() error {
+	column := TenantColumn(s.tableName)
+	if column == "" || s.ignoreTenant {
+		return nil
+	}
+	maps := append([]map[string]any{s.update}, s.updates...)
+	for _, m := range maps {
+		if v, ok := m[column]; ok && (!s.hasTenant || fmt.Sprint(v) != fmt.Sprint(s.tenant)) {
+			return fmt.Errorf("%s cannot be updated to %v", column, v)
+		}
+	}
+	return nil
+}
+
+// softDeleteSql 软删除，update table set column = now() where ... and column is null
+func (s *Generator) softDeleteSql(table, column string, prepare bool) (string, []any, error) {
+	c, err := s.dialect.identifier(column)
+	if err != nil {
+		return "", nil, err
+	}
+	var sql bytes.Buffer
+	sql.WriteString(fmt.Sprintf("update %s set %s=now()", table, c))
+	scopes, err := s.writeScopes()
+	if err != nil {
+		return "", nil, err
+	}
+	scopes = append(scopes, NewNullQueryWithTable(s.physicalTable(), column))
+	params, n, err := s.writeWhere(&sql, s.physicalTable(), scopes, prepare)
+	if err != nil {
+		return "", nil, err
+	}
+	param, err := s.writeGuard(&sql, n, prepare)
+	if err != nil {
+		return "", nil, err
+	}
+	return sql.String(), append(params, param...), nil
+}
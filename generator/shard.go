@@ -0,0 +1,459 @@
+package generator
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"fmt"
+	"hash/crc32"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	SHARD_BY_DAY   = "20060102" // 按天分表 order_20240102
+	SHARD_BY_MONTH = "200601"   // 按月分表 order_202401
+	SHARD_BY_YEAR  = "2006"     // 按年分表 order_2024
+)
+
+var shards sync.Map // 表名 -> 分表策略
+
+// ShardStrategy 分表策略，根据分表字段的值计算物理表名
+type ShardStrategy interface {
+	Column() string                                // 分表字段
+	Table(table string, value any) (string, error) // 分表字段的值对应的物理表
+	Tables(table string) []string                  // 全部物理表，条件中没有分表字段时在这些表上 union all 查询
+}
+
+// ShardSql 某张分表上执行的sql
+type ShardSql struct {
+	Table  string
+	Sql    string
+	Params []any
+}
+
+// HashShard 按分表字段取模分表，整数直接取模，字符串先计算crc32，例如 user_id % 64 对应 order_00 - order_63
+type HashShard struct {
+	column string
+	count  int
+}
+
+func NewHashShard(column string, count int) *HashShard {
+	return &HashShard{column: column, count: count}
+}
+func (h *HashShard) Column() string {
+	return h.column
+}
+func (h *HashShard) Table(table string, value any) (string, error) {
+	if h.count <= 0 {
+		return "", fmt.Errorf("invalid shard count %d", h.count)
+	}
+	n, err := shardHash(value)
+	if err != nil {
+		return "", err
+	}
+	return h.suffix(table, int(n%uint64(h.count))), nil
+}
+func (h *HashShard) Tables(table string) []string {
+	tables := make([]string, 0, h.count)
+	for i := 0; i < h.count; i++ {
+		tables = append(tables, h.suffix(table, i))
+	}
+	return tables
+}
+
+// suffix 分表序号补齐到相同位数
+func (h *HashShard) suffix(table string, i int) string {
+	return fmt.Sprintf("%s_%0*d", table, len(strconv.Itoa(h.count-1)), i)
+}
+
+// RangeShard 按范围分表，bounds 为每张表的上限(不包含)，依次对应 table_0、table_1 ...
+type RangeShard struct {
+	column string
+	bounds []int64
+}
+
+func NewRangeShard(column string, bounds ...int64) *RangeShard {
+	return &RangeShard{column: column, bounds: bounds}
+}
+func (r *RangeShard) Column() string {
+	return r.column
+}
+func (r *RangeShard) Table(table string, value any) (string, error) {
+	v, err := intValue(value)
+	if err != nil {
+		return "", err
+	}
+	for i, bound := range r.bounds {
+		if v < bound {
+			return fmt.Sprintf("%s_%d", table, i), nil
+		}
+	}
+	return "", fmt.Errorf("%s %d is out of shard range", r.column, v)
+}
+func (r *RangeShard) Tables(table string) []string {
+	tables := make([]string, 0, len(r.bounds))
+	for i := range r.bounds {
+		tables = append(tables, fmt.Sprintf("%s_%d", table, i))
+	}
+	return tables
+}
+
+// DateShard 按日期分表，layout 为 SHARD_BY_* 常量，from、to 为分表覆盖的时间范围，时区使用 from 的时区
+type DateShard struct {
+	column string
+	layout string
+	from   time.Time
+	to     time.Time
+}
+
+func NewDateShard(column, layout string, from, to time.Time) *DateShard {
+	return &DateShard{column: column, layout: layout, from: from, to: to}
+}
+func (d *DateShard) Column() string {
+	return d.column
+}
+func (d *DateShard) Table(table string, value any) (string, error) {
+	t, err := shardTime(value, d.from.Location())
+	if err != nil {
+		return "", err
+	}
+	suffix := t.In(d.from.Location()).Format(d.layout)
+	if period, _ := time.ParseInLocation(d.layout, suffix, d.from.Location()); period.Before(d.start()) || period.After(d.to) {
+		return "", fmt.Errorf("%s %v is out of shard range", d.column, t)
+	}
+	return table + "_" + suffix, nil
+}
+func (d *DateShard) Tables(table string) []string {
+	tables := make([]string, 0)
+	for t := d.start(); !t.After(d.to); t = d.next(t) {
+		tables = append(tables, table+"_"+t.Format(d.layout))
+	}
+	return tables
+}
+
+// start from 所在周期的开始时间
+func (d *DateShard) start() time.Time {
+	t, _ := time.ParseInLocation(d.layout, d.from.Format(d.layout), d.from.Location())
+	return t
+}
+func (d *DateShard) next(t time.Time) time.Time {
+	switch {
+	case strings.Contains(d.layout, "02"):
+		return t.AddDate(0, 0, 1)
+	case strings.Contains(d.layout, "01"):
+		return t.AddDate(0, 1, 0)
+	}
+	return t.AddDate(1, 0, 0)
+}
+
+// RegisterShard 注册分表，Table 设置为逻辑表名，生成sql时根据条件或插入的数据计算物理表
+func RegisterShard(table string, strategy ShardStrategy) {
+	shards.Store(table, strategy)
+}
+
+// Shard 主表的分表策略，优先于 RegisterShard 注册的策略
+func (s *Generator) Shard(strategy ShardStrategy) *Generator {
+	s.shard = strategy
+	return s
+}
+
+func (s *Generator) shardStrategy() ShardStrategy {
+	if s.shard != nil {
+		return s.shard
+	}
+	if strategy, ok := shards.Load(s.tableName); ok {
+		return strategy.(ShardStrategy)
+	}
+	return nil
+}
+
+// physicalTable 实际读写的表，分表时为计算出的物理表
+func (s *Generator) physicalTable() string {
+	if s.shardTable != "" {
+		return s.shardTable
+	}
+	return s.tableName
+}
+
+// onShard 在指定的物理表上生成sql，逻辑表名作为别名，条件中的表名不需要修改
+func (s *Generator) onShard(table string) *Generator {
+	c := s.Clone()
+	c.shardTable = table
+	return c
+}
+
+// shardTables 根据条件中的分表字段计算需要读写的物理表，不是分表时返回nil
+// 多个条件之间是 or 的关系，每个条件都限定了分表字段时才能确定物理表，否则返回全部分表
+func (s *Generator) shardTables() ([]string, error) {
+	strategy := s.shardStrategy()
+	if strategy == nil || s.shardTable != "" {
+		return nil, nil
+	}
+	values, ok := s.shardValues(strategy.Column())
+	if !ok || len(values) == 0 {
+		return strategy.Tables(s.tableName), nil
+	}
+	tables := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, value := range values {
+		table, err := strategy.Table(s.tableName, value)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[table] {
+			seen[table] = true
+			tables = append(tables, table)
+		}
+	}
+	return tables, nil
+}
+
+func (s *Generator) shardValues(column string) ([]any, bool) {
+	if column == TenantColumn(s.tableName) && s.hasTenant && !s.ignoreTenant {
+		return []any{s.tenant}, true
+	}
+	if len(s.querys) == 0 {
+		return nil, false
+	}
+	values := make([]any, 0)
+	for _, query := range s.querys {
+		v, ok := s.shardQueryValues(query, column)
+		if !ok {
+			return nil, false
+		}
+		values = append(values, v...)
+	}
+	return values, true
+}
+
+// shardQueryValues 条件限定的分表字段的值，支持等于、in 和 BoolQuery
+func (s *Generator) shardQueryValues(query Query, column string) ([]any, bool) {
+	switch q := query.(type) {
+	case *EqualQuery:
+		if s.isShardField(q.table, q.field, column) {
+			return []any{q.value}, true
+		}
+	case *InQuery:
+		if s.isShardField(q.table, q.field, column) {
+			return q.value, true
+		}
+	case *BoolQuery:
+		for _, child := range q.query {
+			if v, ok := s.shardQueryValues(child, column); ok {
+				return v, true
+			}
+		}
+		if len(q.orQuery) == 0 {
+			return nil, false
+		}
+		values := make([]any, 0)
+		for _, child := range q.orQuery {
+			v, ok := s.shardQueryValues(child, column)
+			if !ok {
+				return nil, false
+			}
+			values = append(values, v...)
+		}
+		return values, true
+	}
+	return nil, false
+}
+
+func (s *Generator) isShardField(table, field, column string) bool {
+	if table != "" && table != s.queryTable() && table != s.tableName {
+		return false
+	}
+	return field == column || field == s.queryTable()+"."+column || field == s.tableName+"."+column
+}
+
+// writeShard 更新、删除只能在一张分表上执行，返回在该分表上生成sql的 Generator，不是分表时返回nil
+func (s *Generator) writeShard() (*Generator, error) {
+	tables, err := s.shardTables()
+	if err != nil || tables == nil {
+		return nil, err
+	}
+	if len(tables) != 1 {
+		return nil, fmt.Errorf("update or delete on %s needs shard column %s in where", s.tableName, s.shardStrategy().Column())
+	}
+	return s.onShard(tables[0]), nil
+}
+
+// shardSelectSql 在多张分表上查询，用 union all 合并，排序、分页在合并后的结果上进行
+// 分页时每张分表只取前 offset+pageSize 条，每个分支加括号
+func (s *Generator) shardSelectSql(tables []string, prepare bool) (string, []any, error) {
+	shard := s.Clone()
+	render := (*Generator).SelectSql
+	if s.pageSize > 0 {
+		shard.pageNum, shard.pageStart, shard.pageSize = 0, 0, s.offset()+s.pageSize
+		render = func(g *Generator, prepare bool) (string, []any, error) {
+			sql, params, err := g.SelectSql(prepare)
+			return "(" + sql + ")", params, err
+		}
+	} else {
+		shard.orderBy = nil
+	}
+	union, params, err := shard.unionSql(tables, render, prepare)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(s.orderBy) == 0 && s.pageSize <= 0 {
+		return union, params, nil
+	}
+	//合并后的结果没有表名，排序字段和表达式中的字段去掉表名
+	outer := &Generator{dialect: s.dialect, pageNum: s.pageNum, pageStart: s.pageStart, pageSize: s.pageSize}
+	outer.dialect.bare = true
+	for _, v := range s.orderBy {
+		v.name = v.name[strings.LastIndex(v.name, ".")+1:]
+		outer.orderBy = append(outer.orderBy, v)
+	}
+	var sql bytes.Buffer
+	sql.WriteString("select * from (" + union + ") t")
+	param, err := outer.writeOrderBy(&sql, "", prepare)
+	if err != nil {
+		return "", nil, err
+	}
+	params = append(params, param...)
+	params = append(params, outer.writeLimit(&sql, prepare)...)
+	return sql.String(), params, nil
+}
+
+// shardCountSql 在多张分表上统计后求和，设置了查询字段时不求和，每张分表返回各自的统计结果，需要调用方相加
+func (s *Generator) shardCountSql(tables []string, prepare bool) (string, []any, error) {
+	union, params, err := s.unionSql(tables, (*Generator).CountSql, prepare)
+	if err != nil {
+		return "", nil, err
+	}
+	if s.columns != nil || s.resultExprs != nil {
+		return union, params, nil
+	}
+	return "select sum(count) count from (" + union + ") t", params, nil
+}
+
+// unionSql 把 render 依次在每张分表上生成的sql用 union all 连接
+func (s *Generator) unionSql(tables []string, render func(*Generator, bool) (string, []any, error), prepare bool) (string, []any, error) {
+	sources := make([]string, 0, len(tables))
+	params := make([]any, 0)
+	for _, table := range tables {
+		sql, param, err := render(s.onShard(table), prepare)
+		if err != nil {
+			return "", nil, err
+		}
+		sources = append(sources, sql)
+		params = append(params, param...)
+	}
+	return strings.Join(sources, " union all "), params, nil
+}
+
+// InsertShardSqls 按分表字段把插入的数据拆分到各自的分表，每张分表生成一条插入语句
+// 不是分表时只返回一条
+func (s *Generator) InsertShardSqls(prepare bool) ([]ShardSql, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	strategy := s.shardStrategy()
+	if strategy == nil || s.shardTable != "" {
+		sql, params, err := s.InsertSql(prepare)
+		if err != nil {
+			return nil, err
+		}
+		return []ShardSql{{Table: s.physicalTable(), Sql: sql, Params: params}}, nil
+	}
+	insert, inserts, err := s.tenantInserts()
+	if err != nil {
+		return nil, err
+	}
+	if len(inserts) == 0 {
+		inserts = []map[string]any{insert}
+	}
+	tables := make([]string, 0)
+	groups := make(map[string][]map[string]any)
+	for _, m := range inserts {
+		value, ok := m[strategy.Column()]
+		if !ok {
+			return nil, fmt.Errorf("insert into %s needs shard column %s", s.tableName, strategy.Column())
+		}
+		table, err := strategy.Table(s.tableName, value)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := groups[table]; !ok {
+			tables = append(tables, table)
+		}
+		groups[table] = append(groups[table], m)
+	}
+	sqls := make([]ShardSql, 0, len(tables))
+	for _, table := range tables {
+		shard := s.onShard(table)
+		if len(s.inserts) > 0 {
+			shard.insert, shard.inserts = nil, groups[table]
+		} else {
+			shard.insert, shard.inserts = groups[table][0], nil
+		}
+		sql, params, err := shard.InsertSql(prepare)
+		if err != nil {
+			return nil, err
+		}
+		sqls = append(sqls, ShardSql{Table: table, Sql: sql, Params: params})
+	}
+	return sqls, nil
+}
+
+// shardHash 取模分表使用的值，整数和整数字符串取绝对值，其余字符串计算crc32
+func shardHash(value any) (uint64, error) {
+	if v, err := intValue(value); err == nil {
+		if v < 0 {
+			v = -v
+		}
+		return uint64(v), nil
+	}
+	switch v := value.(type) {
+	case string:
+		return uint64(crc32.ChecksumIEEE([]byte(v))), nil
+	case []byte:
+		return uint64(crc32.ChecksumIEEE(v)), nil
+	}
+	return 0, fmt.Errorf("unsupported shard value %v(%T)", value, value)
+}
+
+func intValue(value any) (int64, error) {
+	if v, ok := value.(driver.Valuer); ok {
+		val, err := v.Value()
+		if err != nil {
+			return 0, err
+		}
+		value = val
+	}
+	if v, ok := value.(string); ok {
+		return strconv.ParseInt(v, 10, 64)
+	}
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(rv.Uint()), nil
+	}
+	return 0, fmt.Errorf("unsupported shard value %v(%T)", value, value)
+}
+
+// shardTime 按日期分表使用的时间，字符串按 2006-01-02 15:04:05 或 2006-01-02 在 loc 时区解析
+func shardTime(value any, loc *time.Location) (time.Time, error) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, nil
+	case *time.Time:
+		if v != nil {
+			return *v, nil
+		}
+	case string:
+		for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02"} {
+			if t, err := time.ParseInLocation(layout, v, loc); err == nil {
+				return t, nil
+			}
+		}
+	}
+	return time.Time{}, fmt.Errorf("unsupported shard value %v(%T)", value, value)
+}
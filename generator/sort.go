@@ -0,0 +1,118 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+)
+
+const (
+	NULLS_FIRST = "first" // null 排在最前面
+	NULLS_LAST  = "last"  // null 排在最后面
+)
+
+// Sort 排序，由 Asc、Desc、FieldOrder 创建，通过 Generator.Sort 使用
+type Sort struct {
+	orderBy
+	err error
+}
+
+// newSort column 为字段名、查询字段的别名或 *Expr
+func newSort(column any, orderByType string) *Sort {
+	switch c := column.(type) {
+	case string:
+		if err := ValidateIdentifier(c); err != nil {
+			return &Sort{err: err}
+		}
+		return &Sort{orderBy: orderBy{name: c, orderByType: orderByType}}
+	case *Expr:
+		return &Sort{orderBy: orderBy{expr: c, orderByType: orderByType}}
+	}
+	return &Sort{err: fmt.Errorf("sort column must be string or *Expr, got %T", column)}
+}
+
+// Asc 升序，column 为字段名、查询字段的别名或 *Expr
+func Asc(column any) *Sort {
+	return newSort(column, ORDER_ASC)
+}
+
+// Desc 降序，column 为字段名、查询字段的别名或 *Expr
+func Desc(column any) *Sort {
+	return newSort(column, ORDER_DESC)
+}
+
+// FieldOrder 按 values 的顺序排序，不在 values 中的排在最后，相当于 mysql 的 order by field()
+func FieldOrder(column any, values ...any) *Sort {
+	sort := newSort(column, ORDER_ASC)
+	sort.values = values
+	return sort
+}
+
+// NullsFirst null 排在最前面，mysql 使用 字段 is null desc 实现
+func (s *Sort) NullsFirst() *Sort {
+	s.nulls = NULLS_FIRST
+	return s
+}
+
+// NullsLast null 排在最后面，mysql 使用 字段 is null asc 实现
+func (s *Sort) NullsLast() *Sort {
+	s.nulls = NULLS_LAST
+	return s
+}
+
+// Sort 追加排序
+func (s *Generator) Sort(sorts ...*Sort) *Generator {
+	for _, sort := range sorts {
+		if sort.err != nil {
+			s.setErr(sort.err)
+			return s
+		}
+		s.orderBy = append(s.orderBy, sort.orderBy)
+	}
+	return s
+}
+
+// source 排序的字段或表达式，不包含排序方式
+func (o orderBy) source(dialect Dialect, table string, prepare bool) (string, []any, error) {
+	if o.expr == nil {
+		column, err := dialect.identifier(o.name)
+		return column, nil, err
+	}
+	return o.expr.Source(dialect, table, prepare)
+}
+
+// write 写入一项排序，values 不为空时生成 CASE 字段 WHEN 值 THEN 序号 ... END，nulls 在 mysql 中用 is null 模拟
+func (o orderBy) write(sql *bytes.Buffer, dialect Dialect, table string, prepare bool) ([]any, error) {
+	column, params, err := o.source(dialect, table, prepare)
+	if err != nil {
+		return nil, err
+	}
+	if len(o.values) > 0 {
+		var buf bytes.Buffer
+		buf.WriteString("CASE " + column)
+		for i, v := range o.values {
+			value := PLACE_HOLDER_GO
+			if !prepare {
+				value = literal(dialect, v)
+			}
+			buf.WriteString(fmt.Sprintf(" WHEN %s THEN %d", value, i))
+		}
+		buf.WriteString(fmt.Sprintf(" ELSE %d END", len(o.values)))
+		params = append(params, o.values...)
+		column = buf.String()
+	}
+	if o.nulls == "" {
+		sql.WriteString(column + " " + o.orderByType)
+		return params, nil
+	}
+	if dialect.isPostgres() {
+		sql.WriteString(column + " " + o.orderByType + " nulls " + o.nulls)
+		return params, nil
+	}
+	if o.nulls == NULLS_FIRST {
+		sql.WriteString(column + " is null desc, ")
+	} else {
+		sql.WriteString(column + " is null asc, ")
+	}
+	sql.WriteString(column + " " + o.orderByType)
+	return append(params, params...), nil
+}
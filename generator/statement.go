@@ -0,0 +1,133 @@
+package generator
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// Slot 编译语句中的命名参数，作为条件、更新、插入的值使用，Statement.Bind 时替换成实际的值
+// 没有绑定就执行时 Value 返回错误
+type Slot string
+
+func (s Slot) Value() (driver.Value, error) {
+	return nil, fmt.Errorf("slot %s is not bound", string(s))
+}
+
+// slotParam 参数中 Slot 的位置，array 为 postgres 的数组参数 = ANY(?)，绑定时把 slice 转换成 PgArray
+type slotParam struct {
+	index int
+	array bool
+}
+
+// Statement 编译后的预处理语句，sql 只生成一次，每次执行时只替换 Slot 的值
+// 可以在多个 goroutine 中同时使用
+type Statement struct {
+	sql    string
+	params []any
+	slots  map[string][]slotParam
+	names  []string
+}
+
+// Compile 编译成可以重复使用的语句，语句类型和 DebugString 相同，按 StatementKind 选择，没有指定时设置了insert为插入，设置了update为更新，否则为查询
+// 需要变化的值使用 Slot，in 条件的值个数不同时sql不同，in 的 Slot 只能绑定一个值，postgres 使用 ArrayIn 时可以绑定 slice
+func (s *Generator) Compile() (*Statement, error) {
+	if s.shardStrategy() != nil {
+		return nil, errors.New("compiled statement does not support shard tables")
+	}
+	return NewStatement(s.debugSql())
+}
+
+// NewStatement 用生成的预处理sql创建语句，用于统计等，如 NewStatement(gen.CountSql(true))
+func NewStatement(sql string, params []any, err error) (*Statement, error) {
+	if err != nil {
+		return nil, err
+	}
+	stmt := &Statement{sql: sql, params: params, slots: make(map[string][]slotParam)}
+	for i, param := range params {
+		name, array := "", false
+		switch p := param.(type) {
+		case Slot:
+			name = string(p)
+		case PgArray:
+			if len(p) != 1 {
+				continue
+			}
+			slot, ok := p[0].(Slot)
+			if !ok {
+				continue
+			}
+			name, array = string(slot), true
+		default:
+			continue
+		}
+		if _, ok := stmt.slots[name]; !ok {
+			stmt.names = append(stmt.names, name)
+		}
+		stmt.slots[name] = append(stmt.slots[name], slotParam{index: i, array: array})
+	}
+	return stmt, nil
+}
+
+// Sql 预处理sql，占位符为 PLACE_HOLDER_GO
+func (s *Statement) Sql() string {
+	return s.sql
+}
+
+// Slots 语句中的 Slot，按出现的顺序
+func (s *Statement) Slots() []string {
+	return cloneSlice(s.names)
+}
+
+// Bind 用 values 替换 Slot 生成参数，每个 Slot 都要有值，values 中不能有语句中没有的 Slot
+func (s *Statement) Bind(values map[string]any) ([]any, error) {
+	if len(values) != len(s.slots) {
+		for name := range values {
+			if _, ok := s.slots[name]; !ok {
+				return nil, fmt.Errorf("unknown slot %s", name)
+			}
+		}
+	}
+	params := make([]any, len(s.params))
+	copy(params, s.params)
+	for name, slots := range s.slots {
+		value, ok := values[name]
+		if !ok {
+			return nil, fmt.Errorf("slot %s is not bound", name)
+		}
+		for _, slot := range slots {
+			if !slot.array {
+				params[slot.index] = value
+				continue
+			}
+			array, err := toPgArray(value)
+			if err != nil {
+				return nil, fmt.Errorf("slot %s: %w", name, err)
+			}
+			params[slot.index] = array
+		}
+	}
+	return params, nil
+}
+
+// toPgArray slice 转换成 PgArray，其他值作为只有一个元素的数组
+func toPgArray(value any) (PgArray, error) {
+	switch v := value.(type) {
+	case PgArray:
+		return v, nil
+	case []any:
+		return PgArray(v), nil
+	case []byte:
+		return nil, errors.New("[]byte is not supported in array")
+	}
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return PgArray{value}, nil
+	}
+	array := make(PgArray, rv.Len())
+	for i := range array {
+		array[i] = rv.Index(i).Interface()
+	}
+	return array, nil
+}
@@ -0,0 +1,221 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	TIME_UNIT_DAY   = "day"   // 按天
+	TIME_UNIT_WEEK  = "week"  // 按周，周一开始
+	TIME_UNIT_MONTH = "month" // 按月
+)
+
+var timeLocation atomic.Value // 计算时间范围使用的时区
+
+// SetTimeLocation 设置计算日期范围使用的时区，默认 time.Local
+func SetTimeLocation(loc *time.Location) {
+	timeLocation.Store(loc)
+}
+
+// TimeLocation 计算日期范围使用的时区
+func TimeLocation() *time.Location {
+	if loc, ok := timeLocation.Load().(*time.Location); ok && loc != nil {
+		return loc
+	}
+	return time.Local
+}
+
+// TimeRangeQuery 左闭右开的时间范围 字段 >= start and 字段 < end，可以使用字段上的索引
+// 按天、周、月创建时在渲染时按时区计算边界，时区优先使用 In 设置的，其次是 SetTimeLocation 设置的
+type TimeRangeQuery struct {
+	table string
+	field string
+	unit  string
+	at    time.Time
+	count int
+	start time.Time
+	end   time.Time
+	loc   *time.Location
+}
+
+// NewTimeRangeQuery 指定边界的时间范围 [start, end)
+func NewTimeRangeQuery(field string, start, end time.Time) *TimeRangeQuery {
+	return &TimeRangeQuery{field: field, start: start, end: end}
+}
+func NewTimeRangeQueryWithTable(table, field string, start, end time.Time) *TimeRangeQuery {
+	return &TimeRangeQuery{table: table, field: field, start: start, end: end}
+}
+
+// NewSameDayQuery 与 t 同一天
+func NewSameDayQuery(field string, t time.Time) *TimeRangeQuery {
+	return &TimeRangeQuery{field: field, unit: TIME_UNIT_DAY, at: t, count: 1}
+}
+
+// NewTodayQuery 今天
+func NewTodayQuery(field string) *TimeRangeQuery {
+	return NewSameDayQuery(field, time.Now())
+}
+
+// NewSameWeekQuery 与 t 同一周，周一开始
+func NewSameWeekQuery(field string, t time.Time) *TimeRangeQuery {
+	return &TimeRangeQuery{field: field, unit: TIME_UNIT_WEEK, at: t, count: 1}
+}
+
+// NewSameMonthQuery 与 t 同一个月
+func NewSameMonthQuery(field string, t time.Time) *TimeRangeQuery {
+	return &TimeRangeQuery{field: field, unit: TIME_UNIT_MONTH, at: t, count: 1}
+}
+
+// NewLastNDaysQuery 最近n天，包含今天
+func NewLastNDaysQuery(field string, n int) *TimeRangeQuery {
+	return NewLastNDaysQueryAt(field, n, time.Now())
+}
+
+// NewLastNDaysQueryAt 截止到 t 所在的这一天的最近n天，包含 t 所在的这一天
+func NewLastNDaysQueryAt(field string, n int, t time.Time) *TimeRangeQuery {
+	return &TimeRangeQuery{field: field, unit: TIME_UNIT_DAY, at: t, count: n}
+}
+
+// Table 字段所在的表，默认为 Generator 的表
+func (q *TimeRangeQuery) Table(table string) *TimeRangeQuery {
+	q.table = table
+	return q
+}
+
+// In 计算边界使用的时区
+func (q *TimeRangeQuery) In(loc *time.Location) *TimeRangeQuery {
+	q.loc = loc
+	return q
+}
+
+// Bounds 时间范围的边界 [start, end)
+func (q *TimeRangeQuery) Bounds() (start, end time.Time, err error) {
+	if q.unit == "" {
+		if !q.end.After(q.start) {
+			return time.Time{}, time.Time{}, fmt.Errorf("time range end %v must be after start %v", q.end, q.start)
+		}
+		return q.start, q.end, nil
+	}
+	if q.count <= 0 {
+		return time.Time{}, time.Time{}, fmt.Errorf("time range count must be positive, got %d", q.count)
+	}
+	loc := q.loc
+	if loc == nil {
+		loc = TimeLocation()
+	}
+	t := q.at.In(loc)
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+	switch q.unit {
+	case TIME_UNIT_WEEK:
+		start = day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+		return start, start.AddDate(0, 0, 7*q.count), nil
+	case TIME_UNIT_MONTH:
+		start = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, loc)
+		return start, start.AddDate(0, q.count, 0), nil
+	}
+	end = day.AddDate(0, 0, 1)
+	return end.AddDate(0, 0, -q.count), end, nil
+}
+
+func (q *TimeRangeQuery) Source(table string, prepare bool) (string, []any, error) {
+	return q.DialectSource(Dialect{}, table, prepare)
+}
+
+func (q *TimeRangeQuery) DialectSource(dialect Dialect, table string, prepare bool) (string, []any, error) {
+	if q.table != "" {
+		table = q.table
+	}
+	start, end, err := q.Bounds()
+	if err != nil {
+		return "", nil, err
+	}
+	column, err := dialect.column(table, q.field)
+	if err != nil {
+		return "", nil, err
+	}
+	if prepare {
+		return fmt.Sprintf("(%s >= %s and %s < %s)", column, PLACE_HOLDER_GO, column, PLACE_HOLDER_GO), []any{start, end}, nil
+	}
+	return fmt.Sprintf("(%s >= %s and %s < %s)", column, literal(dialect, start), column, literal(dialect, end)), []any{start, end}, nil
+}
+
+// dateGroup 按日期分组的字段
+type dateGroup struct {
+	field string
+	unit  string
+	alias string
+	loc   *time.Location
+}
+
+// GroupByDate 按天、周、月分组统计，查询结果的最前面增加日期字段 alias
+// 天的格式为 2006-01-02，月为 2006-01，周为 年-周数，如 2024-07
+// 设置了 SetTimeLocation 时按该时区分组，字段按 UTC 存储，mysql 使用 convert_tz，postgres 的字段为 timestamptz，使用 at time zone
+func (s *Generator) GroupByDate(field, unit, alias string) *Generator {
+	return s.GroupByDateIn(field, unit, alias, nil)
+}
+
+// GroupByDateIn 按 loc 时区的日期分组，loc 为 nil 时和 GroupByDate 相同
+func (s *Generator) GroupByDateIn(field, unit, alias string, loc *time.Location) *Generator {
+	if err := ValidateIdentifier(field); err != nil {
+		s.setErr(err)
+		return s
+	}
+	if err := ValidateIdentifier(alias); err != nil {
+		s.setErr(err)
+		return s
+	}
+	if unit != TIME_UNIT_DAY && unit != TIME_UNIT_WEEK && unit != TIME_UNIT_MONTH {
+		s.setErr(fmt.Errorf("unsupported date unit %q", unit))
+		return s
+	}
+	s.dateGroups = append(s.dateGroups, dateGroup{field: field, unit: unit, alias: alias, loc: loc})
+	return s
+}
+
+// zoneName 时区在sql中的名称，没有名称的时区(如 time.Local)使用当前的偏移，如 +08:00
+func zoneName(loc *time.Location) (string, error) {
+	name := loc.String()
+	if name == "" || name == "Local" {
+		name = time.Now().In(loc).Format("-07:00")
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.IndexByte("/_+-:", c) >= 0) {
+			return "", fmt.Errorf("invalid time zone %q", name)
+		}
+	}
+	return name, nil
+}
+
+// dateGroupSource 按日期分组的表达式，mysql 使用 date_format，postgres 使用 to_char
+// 时区直接写在sql中，查询字段和 group by 的表达式完全相同
+func (s *Generator) dateGroupSource(group dateGroup) (string, error) {
+	column, err := s.dialect.column(s.queryTable(), group.field)
+	if err != nil {
+		return "", err
+	}
+	loc := group.loc
+	if loc == nil {
+		loc, _ = timeLocation.Load().(*time.Location)
+	}
+	if loc != nil {
+		zone, err := zoneName(loc)
+		if err != nil {
+			return "", err
+		}
+		if s.dialect.isPostgres() {
+			column = fmt.Sprintf("(%s at time zone %s)", column, literal(s.dialect, zone))
+		} else {
+			column = fmt.Sprintf("convert_tz(%s, '+00:00', %s)", column, literal(s.dialect, zone))
+		}
+	}
+	if s.dialect.isPostgres() {
+		format := map[string]string{TIME_UNIT_DAY: "YYYY-MM-DD", TIME_UNIT_WEEK: "IYYY-IW", TIME_UNIT_MONTH: "YYYY-MM"}[group.unit]
+		return fmt.Sprintf("to_char(%s, '%s')", column, format), nil
+	}
+	format := map[string]string{TIME_UNIT_DAY: "%Y-%m-%d", TIME_UNIT_WEEK: "%x-%v", TIME_UNIT_MONTH: "%Y-%m"}[group.unit]
+	return fmt.Sprintf("date_format(%s, '%s')", column, format), nil
+}
@@ -0,0 +1,314 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+var schemas sync.Map // 表名 -> 表结构
+
+var validateAll atomic.Value // 生成sql时是否都做校验
+
+// schema 表的字段
+type schema struct {
+	columns []string
+	set     map[string]bool
+}
+
+// RegisterSchema 注册表的字段，Validate 时检查语句中用到的字段是否存在，code-generator 生成的 model 会自动注册
+func RegisterSchema(table string, columns ...string) {
+	set := make(map[string]bool, len(columns))
+	for _, column := range columns {
+		set[column] = true
+	}
+	schemas.Store(table, &schema{columns: cloneSlice(columns), set: set})
+}
+
+// SchemaColumns 注册的字段，没有注册时为空
+func SchemaColumns(table string) []string {
+	if sc, ok := schemas.Load(table); ok {
+		return cloneSlice(sc.(*schema).columns)
+	}
+	return nil
+}
+
+// SetValidate 开启后生成sql时都做 Validate 中的校验，校验失败时返回错误，建议只在测试中开启
+func SetValidate(enable bool) {
+	validateAll.Store(enable)
+}
+
+// checked 生成sql，开启了 SetValidate 时校验生成的sql
+func (s *Generator) checked(render func(bool) (string, []any, error), prepare bool) (string, []any, error) {
+	sql, params, err := render(prepare)
+	if err != nil {
+		return "", nil, err
+	}
+	if enable, _ := validateAll.Load().(bool); !enable {
+		return sql, params, nil
+	}
+	if err := s.validate(sql, params, prepare); err != nil {
+		return "", nil, err
+	}
+	return sql, params, nil
+}
+
+// Validate 校验生成的预处理sql：占位符数量和参数数量一致、括号配对、没有空的条件，用到的字段在 RegisterSchema 注册的字段中
+// 语句类型和 DebugString 相同，按 StatementKind 选择，没有指定时设置了insert为插入，设置了update为更新，否则为查询
+func (s *Generator) Validate() error {
+	sql, params, err := s.debugSql()
+	if err != nil {
+		return err
+	}
+	return s.validate(sql, params, true)
+}
+
+func (s *Generator) validate(sql string, params []any, prepare bool) error {
+	if err := validateSql(sql, params, prepare); err != nil {
+		return fmt.Errorf("%w: %s", err, sql)
+	}
+	return s.validateColumns()
+}
+
+// ValidateSql 校验预处理sql：占位符数量和参数数量一致、括号配对、没有空的 where、on、() 和多余的 and、or
+func ValidateSql(sql string, params []any) error {
+	return validateSql(sql, params, true)
+}
+
+func validateSql(sql string, params []any, prepare bool) error {
+	tokens := sqlTokens(sql)
+	if prepare {
+		n := 0
+		for _, token := range tokens {
+			if token == PLACE_HOLDER_GO {
+				n++
+			}
+		}
+		if n != len(params) {
+			return fmt.Errorf("placeholder count %d does not match params count %d", n, len(params))
+		}
+	}
+	depth := 0
+	for i, token := range tokens {
+		prev, next := "", ""
+		if i > 0 {
+			prev = tokens[i-1]
+		}
+		if i+1 < len(tokens) {
+			next = tokens[i+1]
+		}
+		switch token {
+		case "(":
+			depth++
+			if next == ")" && (prev == "" || !isWordToken(prev) || conditionKeywords[prev] || prev == "in") {
+				return fmt.Errorf("empty group ()")
+			}
+		case ")":
+			if depth--; depth < 0 {
+				return fmt.Errorf("unbalanced parentheses")
+			}
+		case "where", "on", "having":
+			if next == "" || next == ")" || clauseEnds[next] {
+				return fmt.Errorf("empty %s", token)
+			}
+		case "and", "or":
+			if prev == "" || prev == "(" || conditionKeywords[prev] || next == "" || next == ")" || clauseEnds[next] {
+				return fmt.Errorf("dangling %s", token)
+			}
+		}
+	}
+	if depth != 0 {
+		return fmt.Errorf("unbalanced parentheses")
+	}
+	return nil
+}
+
+// 后面必须跟条件的关键字
+var conditionKeywords = map[string]bool{"where": true, "on": true, "having": true, "and": true, "or": true, "not": true}
+
+// 条件不能以这些关键字结束
+var clauseEnds = map[string]bool{
+	"where": true, "on": true, "having": true, "and": true, "or": true,
+	"group": true, "order": true, "limit": true, "offset": true, "union": true,
+}
+
+// sqlTokens 把sql拆分成小写的单词、占位符和符号，引号内的内容作为一个整体
+func sqlTokens(sql string) []string {
+	tokens := make([]string, 0)
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case strings.HasPrefix(sql[i:], PLACE_HOLDER_GO):
+			tokens = append(tokens, PLACE_HOLDER_GO)
+			i += len(PLACE_HOLDER_GO)
+		case c == '\'' || c == '"' || c == '`':
+			start := i
+			for i++; i < len(sql); i++ {
+				if sql[i] == '\\' && c == '\'' {
+					i++
+				} else if sql[i] == c {
+					if i+1 < len(sql) && sql[i+1] == c {
+						i++
+						continue
+					}
+					break
+				}
+			}
+			i++
+			if i > len(sql) {
+				i = len(sql)
+			}
+			tokens = append(tokens, sql[start:i])
+		case isWordByte(c):
+			start := i
+			for i < len(sql) && isWordByte(sql[i]) {
+				i++
+			}
+			tokens = append(tokens, strings.ToLower(sql[start:i]))
+		default:
+			tokens = append(tokens, string(c))
+			i++
+		}
+	}
+	return tokens
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c == '.' || c == '$' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isWordToken(token string) bool {
+	return token != "" && isWordByte(token[0])
+}
+
+// validateColumns 检查用到的字段是否在注册的表结构中，没有注册的表、派生表和表达式不检查
+func (s *Generator) validateColumns() error {
+	tables := map[string]string{s.tableName: s.tableName}
+	if s.tableAlias != "" {
+		tables[s.tableAlias] = s.tableName
+	}
+	for _, join := range s.joins {
+		if join.subquery != nil {
+			tables[join.alias] = ""
+			continue
+		}
+		tables[join.tableName] = join.tableName
+		if join.alias != "" {
+			tables[join.alias] = join.tableName
+		}
+	}
+	check := func(defaultTable, field string) error {
+		if ValidateIdentifier(field) != nil {
+			return nil
+		}
+		table, column := defaultTable, field
+		if i := strings.LastIndex(field, "."); i >= 0 {
+			table, column = field[:i], field[i+1:]
+		}
+		table, ok := tables[table]
+		if !ok || table == "" {
+			return nil
+		}
+		sc, ok := schemas.Load(table)
+		if !ok || sc.(*schema).set[column] {
+			return nil
+		}
+		return fmt.Errorf("unknown column %s in table %s", column, table)
+	}
+	checkQueries := func(defaultTable string, queries []Query) error {
+		var err error
+		for _, query := range queries {
+			WalkQuery(query, func(node *Node) bool {
+				if err == nil {
+					err = validateNode(node, defaultTable, check)
+				}
+				return err == nil
+			})
+		}
+		return err
+	}
+
+	aliases := make(map[string]bool)
+	for _, group := range s.dateGroups {
+		aliases[group.alias] = true
+		if err := check(s.queryTable(), group.field); err != nil {
+			return err
+		}
+	}
+	for _, expr := range s.resultExprs {
+		aliases[expr.alias] = true
+	}
+	for _, column := range s.columns {
+		if err := check(s.queryTable(), column); err != nil {
+			return err
+		}
+	}
+	for _, column := range s.groupBy {
+		if err := check(s.queryTable(), column); err != nil {
+			return err
+		}
+	}
+	for _, order := range s.orderBy {
+		if order.expr != nil || aliases[order.name] {
+			continue
+		}
+		if err := check(s.queryTable(), order.name); err != nil {
+			return err
+		}
+	}
+	values := append(cloneSlice(s.updates), s.inserts...)
+	for _, m := range append(values, s.update, s.insert) {
+		for column := range m {
+			if err := check(s.tableName, column); err != nil {
+				return err
+			}
+		}
+	}
+	if err := checkQueries(s.queryTable(), s.querys); err != nil {
+		return err
+	}
+	for _, join := range s.joins {
+		if join.subquery != nil {
+			if err := join.subquery.validateColumns(); err != nil {
+				return err
+			}
+		}
+		for _, column := range join.using {
+			if err := check(join.table(), column); err != nil {
+				return err
+			}
+		}
+		for _, queries := range [][]Query{join.on, join.querys, join.orQuerys} {
+			if err := checkQueries(join.table(), queries); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validateNode 检查一个条件用到的字段
+func validateNode(node *Node, table string, check func(table, field string) error) error {
+	column := func(field string) string {
+		if node.Table != "" && !isQualified(field) {
+			return node.Table + "." + field
+		}
+		return field
+	}
+	fields := cloneSlice(node.Fields)
+	if node.Field != "" {
+		fields = append(fields, node.Field)
+	}
+	if node.Other != "" {
+		fields = append(fields, node.Other)
+	}
+	for _, field := range fields {
+		if err := check(table, column(field)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
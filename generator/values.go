@@ -0,0 +1,315 @@
+package generator
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DEFAULT_SORT_KEY  = "sort" // 排序参数 sort=-create_time,id ，-表示降序
+	DEFAULT_PAGE_KEY  = "page" // 页码参数
+	DEFAULT_SIZE_KEY  = "size" // 每页条数参数
+	DEFAULT_PAGE_SIZE = 20     // 默认每页条数
+	DEFAULT_MAX_SIZE  = 1000   // 每页最多条数
+)
+
+// ValueConverter 把url参数的字符串转换成字段对应的类型
+type ValueConverter func(value string) (any, error)
+
+func StringConverter(value string) (any, error) {
+	return value, nil
+}
+func IntConverter(value string) (any, error) {
+	return strconv.ParseInt(value, 10, 64)
+}
+func FloatConverter(value string) (any, error) {
+	return strconv.ParseFloat(value, 64)
+}
+func BoolConverter(value string) (any, error) {
+	return strconv.ParseBool(value)
+}
+
+// TimeConverter 按 layout 在 loc 时区解析时间
+func TimeConverter(layout string, loc *time.Location) ValueConverter {
+	return func(value string) (any, error) {
+		return time.ParseInLocation(layout, value, loc)
+	}
+}
+
+// ValuesParser 把url参数转换成查询条件、排序、分页，例如
+//
+//	?age_gte=20&status_in=1,2&name_like=foo&sort=-age&page=2&size=10
+//
+// 只有白名单中的字段会被解析，其余参数忽略；不带后缀的参数为等于
+type ValuesParser struct {
+	allow      AllowList
+	sortAllow  AllowList
+	converters map[string]ValueConverter
+	suffixes   map[string]string
+	sortKey    string
+	pageKey    string
+	sizeKey    string
+	maxSize    int
+	maxValues  int
+}
+
+// NewValuesParser allow 为可以过滤的字段白名单，默认也作为排序字段白名单
+func NewValuesParser(allow AllowList) *ValuesParser {
+	return &ValuesParser{
+		allow:      allow,
+		sortAllow:  allow,
+		converters: make(map[string]ValueConverter),
+		suffixes: map[string]string{
+			"_ne":      DSL_NOT_EQUAL,
+			"_gt":      DSL_GT,
+			"_gte":     DSL_GTE,
+			"_lt":      DSL_LT,
+			"_lte":     DSL_LTE,
+			"_like":    DSL_LIKE,
+			"_in":      DSL_IN,
+			"_nin":     DSL_NOT_IN,
+			"_between": DSL_BETWEEN,
+			"_null":    DSL_NULL,
+		},
+		sortKey:   DEFAULT_SORT_KEY,
+		pageKey:   DEFAULT_PAGE_KEY,
+		sizeKey:   DEFAULT_SIZE_KEY,
+		maxSize:   DEFAULT_MAX_SIZE,
+		maxValues: DEFAULT_MAX_VALUES,
+	}
+}
+
+// Suffix 设置参数后缀对应的条件类型，kind 为 DSL_* 常量，kind 为空时删除该后缀
+func (p *ValuesParser) Suffix(suffix, kind string) *ValuesParser {
+	if kind == "" {
+		delete(p.suffixes, suffix)
+	} else {
+		p.suffixes[suffix] = kind
+	}
+	return p
+}
+
+// Convert 设置字段的类型转换，column 为真实列名，默认按字符串处理
+func (p *ValuesParser) Convert(column string, converter ValueConverter) *ValuesParser {
+	p.converters[column] = converter
+	return p
+}
+
+// SortAllow 排序字段白名单，为nil时不解析排序参数
+func (p *ValuesParser) SortAllow(allow AllowList) *ValuesParser {
+	p.sortAllow = allow
+	return p
+}
+func (p *ValuesParser) SortKey(key string) *ValuesParser {
+	p.sortKey = key
+	return p
+}
+func (p *ValuesParser) PageKey(key string) *ValuesParser {
+	p.pageKey = key
+	return p
+}
+func (p *ValuesParser) SizeKey(key string) *ValuesParser {
+	p.sizeKey = key
+	return p
+}
+func (p *ValuesParser) MaxSize(maxSize int) *ValuesParser {
+	p.maxSize = maxSize
+	return p
+}
+func (p *ValuesParser) MaxValues(maxValues int) *ValuesParser {
+	p.maxValues = maxValues
+	return p
+}
+
+// Parse 把url参数中的过滤条件转换成 BoolQuery
+func (p *ValuesParser) Parse(values url.Values) (*BoolQuery, error) {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	query := NewBoolQuery()
+	for _, key := range keys {
+		if key == p.sortKey || key == p.pageKey || key == p.sizeKey {
+			continue
+		}
+		column, kind, ok := p.lookup(key)
+		if !ok {
+			continue
+		}
+		q, err := p.build(column, kind, values[key])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		query.And(q)
+	}
+	return query, nil
+}
+
+// Apply 把url参数中的条件、排序、分页设置到 gen 上
+func (p *ValuesParser) Apply(gen *Generator, values url.Values) (*Generator, error) {
+	query, err := p.Parse(values)
+	if err != nil {
+		return gen, err
+	}
+	if len(query.query) > 0 {
+		gen.Where(query)
+	}
+	if err := p.applySort(gen, values.Get(p.sortKey)); err != nil {
+		return gen, err
+	}
+	if err := p.applyPage(gen, values.Get(p.pageKey), values.Get(p.sizeKey)); err != nil {
+		return gen, err
+	}
+	return gen, nil
+}
+
+// lookup 根据参数名找到真实列名和条件类型，参数名本身在白名单中时为等于
+func (p *ValuesParser) lookup(key string) (string, string, bool) {
+	if column, err := p.allow.Column(key); err == nil {
+		return column, DSL_EQUAL, true
+	}
+	//后缀较长的优先匹配
+	suffixes := make([]string, 0, len(p.suffixes))
+	for suffix := range p.suffixes {
+		suffixes = append(suffixes, suffix)
+	}
+	sort.Slice(suffixes, func(i, j int) bool { return len(suffixes[i]) > len(suffixes[j]) })
+	for _, suffix := range suffixes {
+		if !strings.HasSuffix(key, suffix) {
+			continue
+		}
+		if column, err := p.allow.Column(strings.TrimSuffix(key, suffix)); err == nil {
+			return column, p.suffixes[suffix], true
+		}
+	}
+	return "", "", false
+}
+
+func (p *ValuesParser) build(column, kind string, raws []string) (Query, error) {
+	switch kind {
+	case DSL_IN, DSL_NOT_IN, DSL_BETWEEN:
+		values, err := p.convertAll(column, raws)
+		if err != nil {
+			return nil, err
+		}
+		if kind == DSL_BETWEEN {
+			if len(values) != 2 {
+				return nil, fmt.Errorf("between needs 2 values, got %d", len(values))
+			}
+			return NewBetweenQuery(column, values[0], values[1]), nil
+		}
+		if len(values) > p.maxValues {
+			return nil, fmt.Errorf("values size %d exceeds max %d", len(values), p.maxValues)
+		}
+		if kind == DSL_IN {
+			return NewInQuery(column, values), nil
+		}
+		return NewNotInQuery(column, values), nil
+	case DSL_NULL, DSL_NOT_NULL:
+		isNull, err := strconv.ParseBool(raws[0])
+		if err != nil {
+			return nil, err
+		}
+		if isNull == (kind == DSL_NULL) {
+			return NewNullQuery(column), nil
+		}
+		return NewNotNullQuery(column), nil
+	case DSL_LIKE, DSL_NOT_LIKE:
+		//参数中的 % _ 按普通字符匹配
+		if kind == DSL_LIKE {
+			return NewContainsQuery(column, raws[0]), nil
+		}
+		return NewNotContainsQuery(column, raws[0]), nil
+	}
+
+	value, err := p.convert(column, raws[0])
+	if err != nil {
+		return nil, err
+	}
+	switch kind {
+	case DSL_EQUAL:
+		return NewEqualQuery(column, value), nil
+	case DSL_NOT_EQUAL:
+		return NewNotEqualQuery(column, value), nil
+	case DSL_GT:
+		return NewGreaterThanQuery(column, value), nil
+	case DSL_GTE:
+		return NewGreaterThanOrEqualQuery(column, value), nil
+	case DSL_LT:
+		return NewLessThanQuery(column, value), nil
+	case DSL_LTE:
+		return NewLessThanOrEqualQuery(column, value), nil
+	}
+	return nil, fmt.Errorf("unsupported query type %q", kind)
+}
+
+func (p *ValuesParser) convert(column, raw string) (any, error) {
+	converter, ok := p.converters[column]
+	if !ok {
+		return raw, nil
+	}
+	return converter(raw)
+}
+
+// convertAll 多个值可以用逗号分隔，也可以重复传参
+func (p *ValuesParser) convertAll(column string, raws []string) ([]any, error) {
+	values := make([]any, 0)
+	for _, raw := range raws {
+		for _, v := range strings.Split(raw, ",") {
+			value, err := p.convert(column, v)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+	}
+	return values, nil
+}
+
+func (p *ValuesParser) applySort(gen *Generator, sortValue string) error {
+	if sortValue == "" || p.sortAllow == nil {
+		return nil
+	}
+	for _, key := range strings.Split(sortValue, ",") {
+		orderByType := ORDER_ASC
+		if strings.HasPrefix(key, "-") {
+			orderByType = ORDER_DESC
+			key = key[1:]
+		}
+		column, err := p.sortAllow.Column(key)
+		if err != nil {
+			return err
+		}
+		gen.AddOrderBy(column, orderByType)
+	}
+	return nil
+}
+
+func (p *ValuesParser) applyPage(gen *Generator, pageValue, sizeValue string) error {
+	if pageValue == "" && sizeValue == "" {
+		return nil
+	}
+	page, size := 1, DEFAULT_PAGE_SIZE
+	var err error
+	if pageValue != "" {
+		if page, err = strconv.Atoi(pageValue); err != nil || page < 1 {
+			return fmt.Errorf("invalid page %q", pageValue)
+		}
+	}
+	if sizeValue != "" {
+		if size, err = strconv.Atoi(sizeValue); err != nil || size < 1 {
+			return fmt.Errorf("invalid size %q", sizeValue)
+		}
+	}
+	if size > p.maxSize {
+		size = p.maxSize
+	}
+	gen.PageNum(page).PageSize(size)
+	return nil
+}
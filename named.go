@@ -0,0 +1,187 @@
+package north
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// BindNamed 把sql中的 :name 命名参数替换为占位符，返回替换后的sql和按顺序排列的参数
+// arg 为 map[string]any 或结构体，结构体按 orm tag 匹配，没有tag时按字段名匹配
+// slice 参数展开为多个占位符，用于 in (:ids)；引号内的内容、postgres 的 :: 类型转换、mysql 的 := 和 @变量 不做处理
+func BindNamed(sqlStr string, arg any) (string, []any, error) {
+	return bindNamed(sqlStr, arg, false)
+}
+
+// BindNamedAt 和 BindNamed 相同，@name 也作为命名参数，mysql 的 @变量 不能再使用，@@系统变量 不做处理
+func BindNamedAt(sqlStr string, arg any) (string, []any, error) {
+	return bindNamed(sqlStr, arg, true)
+}
+
+func bindNamed(sqlStr string, arg any, at bool) (string, []any, error) {
+	lookup, err := namedLookup(arg)
+	if err != nil {
+		return "", nil, err
+	}
+	params := make([]any, 0)
+	var buf strings.Builder
+	for i := 0; i < len(sqlStr); i++ {
+		c := sqlStr[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			end := quoteEnd(sqlStr, i)
+			buf.WriteString(sqlStr[i:end])
+			i = end - 1
+			continue
+		case c == '@' && !(at && i+1 < len(sqlStr) && isNameStart(sqlStr[i+1])):
+			//mysql 的用户变量 @name 和系统变量 @@name 原样输出
+			end := i + 1
+			for end < len(sqlStr) && (sqlStr[end] == '@' || isNamePart(sqlStr[end])) {
+				end++
+			}
+			buf.WriteString(sqlStr[i:end])
+			i = end - 1
+			continue
+		case c == ':' && i+1 < len(sqlStr) && (sqlStr[i+1] == ':' || sqlStr[i+1] == '='):
+			buf.WriteString(sqlStr[i : i+2])
+			i++
+			continue
+		case (c == ':' || c == '@') && i+1 < len(sqlStr) && isNameStart(sqlStr[i+1]):
+			end := i + 1
+			for end < len(sqlStr) && isNamePart(sqlStr[end]) {
+				end++
+			}
+			name := sqlStr[i+1 : end]
+			value, ok := lookup(name)
+			if !ok {
+				return "", nil, fmt.Errorf("named param %s not found", name)
+			}
+			values, err := expandNamed(name, value)
+			if err != nil {
+				return "", nil, err
+			}
+			buf.WriteString(strings.TrimSuffix(strings.Repeat(PLACE_HOLDER_GO+", ", len(values)), ", "))
+			params = append(params, values...)
+			i = end - 1
+			continue
+		}
+		buf.WriteByte(c)
+	}
+	return buf.String(), params, nil
+}
+
+// bindParams params 只有一个 map 或结构体并且sql中有命名参数时按命名参数绑定，否则原样返回，at 为是否支持 @name
+func bindParams(sqlStr string, params []any, at bool) (string, []any, error) {
+	if len(params) != 1 || !isNamedArg(params[0]) || !hasNamed(sqlStr, at) {
+		return sqlStr, params, nil
+	}
+	return bindNamed(sqlStr, params[0], at)
+}
+
+func isNamedArg(arg any) bool {
+	switch arg.(type) {
+	case driver.Valuer, time.Time, *time.Time, nil:
+		return false
+	}
+	t := reflect.TypeOf(arg)
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct || t.Kind() == reflect.Map && t.Key().Kind() == reflect.String
+}
+
+// hasNamed sql中是否有命名参数
+func hasNamed(sqlStr string, at bool) bool {
+	_, params, err := bindNamed(sqlStr, map[string]any{}, at)
+	return err != nil || len(params) > 0
+}
+
+// namedLookup 根据参数名获取值的函数
+func namedLookup(arg any) (func(name string) (any, bool), error) {
+	v := reflect.ValueOf(arg)
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil, fmt.Errorf("named arg cannot be nil")
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("named arg map key must be string, got %v", v.Type().Key())
+		}
+		return func(name string) (any, bool) {
+			value := v.MapIndex(reflect.ValueOf(name).Convert(v.Type().Key()))
+			if !value.IsValid() {
+				return nil, false
+			}
+			return value.Interface(), true
+		}, nil
+	case reflect.Struct:
+		fields := make(map[string]int)
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			if tagValue, ok := getFieldTagValue(field, "orm"); ok && tagValue != "" {
+				fields[tagValue] = i
+			} else if _, ok := fields[field.Name]; !ok {
+				fields[field.Name] = i
+			}
+		}
+		return func(name string) (any, bool) {
+			i, ok := fields[name]
+			if !ok {
+				return nil, false
+			}
+			return v.Field(i).Interface(), true
+		}, nil
+	}
+	return nil, fmt.Errorf("named arg must be map or struct, got %T", arg)
+}
+
+// expandNamed slice 展开为多个参数，[]byte 作为一个参数
+func expandNamed(name string, value any) ([]any, error) {
+	v := reflect.ValueOf(value)
+	if value == nil || v.Kind() != reflect.Slice && v.Kind() != reflect.Array || v.Type().Elem().Kind() == reflect.Uint8 {
+		return []any{value}, nil
+	}
+	if v.Len() == 0 {
+		return nil, fmt.Errorf("named param %s is an empty slice", name)
+	}
+	values := make([]any, 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		values = append(values, v.Index(i).Interface())
+	}
+	return values, nil
+}
+
+// quoteEnd 引号结束后的位置，'' 和反斜杠转义的引号不结束
+func quoteEnd(sqlStr string, start int) int {
+	quote := sqlStr[start]
+	for i := start + 1; i < len(sqlStr); i++ {
+		switch sqlStr[i] {
+		case '\\':
+			if quote == '\'' {
+				i++
+			}
+		case quote:
+			if i+1 < len(sqlStr) && sqlStr[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(sqlStr)
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+func isNamePart(c byte) bool {
+	return isNameStart(c) || c >= '0' && c <= '9'
+}
@@ -7,6 +7,7 @@ import (
 	"os"
 	"reflect"
 	"strings"
+	"sync"
 )
 
 const (
@@ -19,10 +20,13 @@ type DataSource struct {
 	Db           *sql.DB
 	DriverName   string
 	DaoFilePaths []string
+	AtNamed      bool     // Prepare* 方法是否也支持 @name 命名参数，开启后 mysql 的 @变量 不能再使用
+	stmts        sync.Map // 编译语句的预处理缓存 sql -> *sql.Stmt
 }
 type Config struct {
 	MaxOpenConns int
 	MaxIdleConns int
+	AtNamed      bool // 同 DataSource.AtNamed
 }
 
 func Open(driverName string, dsn string, config *Config) (*DataSource, error) {
@@ -40,6 +44,7 @@ func Open(driverName string, dsn string, config *Config) (*DataSource, error) {
 	return &DataSource{
 		Db:         db,
 		DriverName: driverName,
+		AtNamed:    config.AtNamed,
 	}, nil
 }
 
@@ -73,6 +78,10 @@ func (ds *DataSource) PrepareCount(sql string, params []any) (int64, error) {
 	if ds.Db == nil {
 		return 0, errors.New("db not allowed to be nil,need to instantiate yourself")
 	}
+	sql, params, err := bindParams(sql, params, ds.AtNamed)
+	if err != nil {
+		return 0, err
+	}
 	sql = prepareConvert(sql, ds.DriverName)
 
 	serverMode := os.Getenv("sql.log")
@@ -125,6 +134,10 @@ func (ds *DataSource) PrepareQuery(sql string, params []any) ([]map[string]any,
 	if ds.Db == nil {
 		return nil, errors.New("db not allowed to be nil,need to instantiate yourself")
 	}
+	sql, params, err := bindParams(sql, params, ds.AtNamed)
+	if err != nil {
+		return nil, err
+	}
 	sql = prepareConvert(sql, ds.DriverName)
 	serverMode := os.Getenv("sql.log")
 	if serverMode == "stdout" {
@@ -149,6 +162,10 @@ func (ds *DataSource) PrepareInsert(sql string, params []any) (int64, error) {
 	if ds.Db == nil {
 		return 0, errors.New("db not allowed to be nil,need to instantiate yourself")
 	}
+	sql, params, err := bindParams(sql, params, ds.AtNamed)
+	if err != nil {
+		return 0, err
+	}
 	sql = prepareConvert(sql, ds.DriverName)
 	serverMode := os.Getenv("sql.log")
 	if serverMode == "stdout" {
@@ -175,6 +192,10 @@ func (ds *DataSource) PrepareUpdate(sql string, params []any) (int64, error) {
 	if ds.Db == nil {
 		return 0, errors.New("db not allowed to be nil,need to instantiate yourself")
 	}
+	sql, params, err := bindParams(sql, params, ds.AtNamed)
+	if err != nil {
+		return 0, err
+	}
 	sql = prepareConvert(sql, ds.DriverName)
 	serverMode := os.Getenv("sql.log")
 	if serverMode == "stdout" {
@@ -195,6 +216,10 @@ func (ds *DataSource) PrepareSave(sql string, params []any) (int64, error) {
 	if ds.Db == nil {
 		return 0, errors.New("db not allowed to be nil,need to instantiate yourself")
 	}
+	sql, params, err := bindParams(sql, params, ds.AtNamed)
+	if err != nil {
+		return 0, err
+	}
 	sql = prepareConvert(sql, ds.DriverName)
 	serverMode := os.Getenv("sql.log")
 	if serverMode == "stdout" {
@@ -215,6 +240,10 @@ func (ds *DataSource) PrepareDelete(sql string, params []any) (int64, error) {
 	if ds.Db == nil {
 		return 0, errors.New("db not allowed to be nil,need to instantiate yourself")
 	}
+	sql, params, err := bindParams(sql, params, ds.AtNamed)
+	if err != nil {
+		return 0, err
+	}
 	sql = prepareConvert(sql, ds.DriverName)
 	serverMode := os.Getenv("sql.log")
 	if serverMode == "stdout" {
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"strings"
 	"testing"
 	"time"
 	// _ "github.com/go-sql-driver/mysql"
@@ -78,3 +79,50 @@ func TestRowsToResults(t *testing.T) {
 	// fmt.Println("type:", reflect.TypeOf(results))
 	// fmt.Println("type:", reflect.TypeOf(results).Kind())
 }
+
+func TestBindNamed(t *testing.T) {
+	sqlStr, params, err := BindNamed("select * from user where id in (:ids) and name = :name and tag = ':x' and age::int > :age", map[string]any{
+		"ids":  []int{1, 2, 3},
+		"name": "lazyer",
+		"age":  18,
+	})
+	if err != nil || sqlStr != "select * from user where id in (ⒼⓄ, ⒼⓄ, ⒼⓄ) and name = ⒼⓄ and tag = ':x' and age::int > ⒼⓄ" || fmt.Sprint(params) != "[1 2 3 lazyer 18]" {
+		t.Errorf("unexpected sql %s %v %v", sqlStr, params, err)
+	}
+
+	type param struct {
+		UserId string `orm:"user_id"`
+		Day    time.Time
+	}
+	day := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	sqlStr, params, err = bindParams("select * from tip_off where user_id = :user_id and day = :Day", []any{&param{UserId: "u1", Day: day}}, false)
+	if err != nil || sqlStr != "select * from tip_off where user_id = ⒼⓄ and day = ⒼⓄ" || len(params) != 2 || params[0] != "u1" || params[1] != day {
+		t.Errorf("unexpected sql %s %v %v", sqlStr, params, err)
+	}
+	if sqlStr, params, _ = bindParams("select * from tip_off where day = ?", []any{day}, false); sqlStr != "select * from tip_off where day = ?" || len(params) != 1 {
+		t.Errorf("positional params should not be bound %s", sqlStr)
+	}
+	rownum := "select @rownum:=@rownum+1 rn, u.* from user u, (select @rownum := 0) r where u.id > :id and @@sql_mode != ''"
+	if sqlStr, params, err = BindNamed(rownum, map[string]any{"id": 1}); err != nil || sqlStr != strings.Replace(rownum, ":id", PLACE_HOLDER_GO, 1) || fmt.Sprint(params) != "[1]" {
+		t.Errorf("unexpected sql %s %v %v", sqlStr, params, err)
+	}
+	if sqlStr, params, _ = bindParams("select * from user where id = @id", []any{map[string]any{"id": 1}}, false); sqlStr != "select * from user where id = @id" || len(params) != 1 {
+		t.Errorf("@name should not be bound by default %s", sqlStr)
+	}
+
+	// 开启 @name 后 @name 和 :name 都作为命名参数，@@系统变量 原样输出
+	sqlStr, params, err = BindNamedAt("select * from user where id = @id and name = :name and tag = '@x' and @@sql_mode != ''", map[string]any{"id": 1, "name": "lazyer"})
+	if err != nil || sqlStr != "select * from user where id = ⒼⓄ and name = ⒼⓄ and tag = '@x' and @@sql_mode != ''" || fmt.Sprint(params) != "[1 lazyer]" {
+		t.Errorf("unexpected sql %s %v %v", sqlStr, params, err)
+	}
+	sqlStr, params, err = bindParams("select * from tip_off where user_id in (@user_id)", []any{map[string]any{"user_id": []string{"u1", "u2"}}}, true)
+	if err != nil || sqlStr != "select * from tip_off where user_id in (ⒼⓄ, ⒼⓄ)" || fmt.Sprint(params) != "[u1 u2]" {
+		t.Errorf("unexpected sql %s %v %v", sqlStr, params, err)
+	}
+	if _, _, err = BindNamed("select * from user where id = :id", map[string]any{}); err == nil {
+		t.Error("missing named param should fail")
+	}
+	if _, _, err = BindNamed("select * from user where id in (:ids)", map[string]any{"ids": []int{}}); err == nil {
+		t.Error("empty slice should fail")
+	}
+}
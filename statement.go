@@ -0,0 +1,86 @@
+package north
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/go-lazyer/go-north/generator"
+)
+
+// preparedStmt 缓存的预处理语句，没有时创建，同一条sql只 Prepare 一次
+func (ds *DataSource) preparedStmt(sqlStr string) (*sql.Stmt, error) {
+	if ds.Db == nil {
+		return nil, errors.New("db not allowed to be nil,need to instantiate yourself")
+	}
+	if stmt, ok := ds.stmts.Load(sqlStr); ok {
+		return stmt.(*sql.Stmt), nil
+	}
+	stmt, err := ds.Db.Prepare(prepareConvert(sqlStr, ds.DriverName))
+	if err != nil {
+		return nil, err
+	}
+	if actual, loaded := ds.stmts.LoadOrStore(sqlStr, stmt); loaded {
+		stmt.Close()
+		return actual.(*sql.Stmt), nil
+	}
+	return stmt, nil
+}
+
+// bindStatement 绑定参数并取出缓存的预处理语句
+func (ds *DataSource) bindStatement(statement *generator.Statement, values map[string]any) (*sql.Stmt, []any, error) {
+	params, err := statement.Bind(values)
+	if err != nil {
+		return nil, nil, err
+	}
+	stmt, err := ds.preparedStmt(statement.Sql())
+	if err != nil {
+		return nil, nil, err
+	}
+	if os.Getenv("sql.log") == "stdout" {
+		fmt.Printf("sql is %v\n", statement.Sql())
+		fmt.Printf("params is %v\n", params)
+	}
+	return stmt, params, nil
+}
+
+// QueryStatement 执行编译后的查询语句，预处理语句缓存在 DataSource 中重复使用
+func (ds *DataSource) QueryStatement(statement *generator.Statement, values map[string]any) ([]map[string]any, error) {
+	stmt, params, err := ds.bindStatement(statement, values)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := stmt.Query(params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return RowsToMapSlice(rows)
+}
+
+// ExecStatement 执行编译后的插入、更新、删除语句，返回影响的行数
+func (ds *DataSource) ExecStatement(statement *generator.Statement, values map[string]any) (int64, error) {
+	stmt, params, err := ds.bindStatement(statement, values)
+	if err != nil {
+		return 0, err
+	}
+	ret, err := stmt.Exec(params...)
+	if err != nil {
+		return 0, err
+	}
+	return ret.RowsAffected()
+}
+
+// CloseStatements 关闭缓存的预处理语句，关闭 Db 之前调用
+func (ds *DataSource) CloseStatements() error {
+	var err error
+	ds.stmts.Range(func(key, value any) bool {
+		if e := value.(*sql.Stmt).Close(); e != nil && err == nil {
+			err = e
+		}
+		ds.stmts.Delete(key)
+		return true
+	})
+	return err
+}